@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CertificatesConfig stores the CLI parameters.
+type CertificatesConfig struct {
+	WarningDays  int
+	CriticalDays int
+	API          bool
+	Presented    bool
+}
+
+const certificatesOutput = "%s (%s): %s"
+
+var cliCertificatesConfig CertificatesConfig
+
+var certificatesCmd = &cobra.Command{
+	Use:   "certificates",
+	Short: "Checks the expiry of the TLS certificates used by Elasticsearch",
+	Long: `Checks the expiry of the TLS certificates used by Elasticsearch
+
+The certificates configured for the HTTP and transport layer are retrieved
+via the SSL certificates API. The certificates presented on the connection to
+the --hostname can be checked as well.
+
+The plugin alerts when a certificate expires within the given number of days.`,
+	Example: `
+$ check_elasticsearch certificates
+[OK] - All 4 certificates are valid for at least 30 days
+
+$ check_elasticsearch certificates --presented --warning 60
+[WARNING] - At least one certificate expires within 60 days
+ \_[OK] certs/transport.p12 (CN=node-1): expires in 300 days (2026-08-15)
+ \_[WARNING] presented by node-1:9200 (CN=node-1): expires in 42 days (2025-11-30)
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliCertificatesConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(certificatesCmd)
+
+	fs := certificatesCmd.Flags()
+	cliCertificatesConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (cc *CertificatesConfig) addFlags(fs *pflag.FlagSet) {
+	fs.IntVarP(&cc.WarningDays, "warning", "w", 30,
+		"Warning threshold for the remaining days until a certificate expires")
+	fs.IntVarP(&cc.CriticalDays, "critical", "c", 7,
+		"Critical threshold for the remaining days until a certificate expires")
+	fs.BoolVar(&cc.API, "api", true,
+		"Check the certificates configured on the nodes via the SSL certificates API")
+	fs.BoolVar(&cc.Presented, "presented", false,
+		"Check the certificates presented on the TLS connection to the --hostname")
+}
+
+func (cc *CertificatesConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		states []check.Status
+		items  []Item
+	)
+
+	warn := expiryThreshold(cc.WarningDays)
+	crit := expiryThreshold(cc.CriticalDays)
+
+	// The lowest number of remaining days of all certificates
+	minRemainingDays := math.Inf(1)
+
+	// The presented certificate failed the verification
+	untrusted := false
+
+	evaluate := func(name, subject string, expiry time.Time) {
+		remainingDays := math.Floor(time.Until(expiry).Hours() / 24)
+		minRemainingDays = math.Min(minRemainingDays, remainingDays)
+
+		var rc check.Status
+
+		if crit.DoesViolate(remainingDays) {
+			rc = check.Critical
+		} else if warn.DoesViolate(remainingDays) {
+			rc = check.Warning
+		} else {
+			rc = check.OK
+		}
+
+		states = append(states, rc)
+
+		var expires string
+		if remainingDays < 0 {
+			expires = fmt.Sprintf("expired %g days ago (%s)", -remainingDays, expiry.Format(time.DateOnly))
+		} else {
+			expires = fmt.Sprintf("expires in %g days (%s)", remainingDays, expiry.Format(time.DateOnly))
+		}
+
+		items = append(items, Item{Status: rc, Output: fmt.Sprintf(certificatesOutput, name, subject, expires)})
+	}
+
+	if cc.API {
+		certificates, err := c.Certificates(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cert := range certificates {
+			evaluate(cert.Path, cert.SubjectDN, cert.Expiry)
+		}
+	}
+
+	if cc.Presented {
+		host, certificates, err := c.PeerCertificates(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(certificates) == 0 {
+			return nil, fmt.Errorf("no certificate presented by %s", host)
+		}
+
+		// Only the expiry of the server's certificate is checked, the CAs of the chain are verified
+		leaf := certificates[0]
+		evaluate("presented by "+host, leaf.Subject.String(), leaf.NotAfter)
+
+		err = c.VerifyPeerCertificates(host, certificates)
+		if err != nil {
+			untrusted = true
+			states[len(states)-1] = check.Critical
+			items[len(items)-1] = Item{Status: check.Critical, Output: items[len(items)-1].Output + ", not trusted: " + err.Error()}
+		}
+	}
+
+	if len(states) == 0 {
+		return &Result{Status: check.Unknown, Summary: "No certificates found"}, nil
+	}
+
+	var output string
+
+	rc := check.WorstState(states...)
+
+	//nolint:exhaustive
+	switch rc {
+	case check.OK:
+		output = fmt.Sprintf("All %d certificates are valid for at least %d days", len(states), cc.WarningDays)
+	case check.Warning:
+		output = fmt.Sprintf("At least one certificate expires within %d days", cc.WarningDays)
+	case check.Critical:
+		output = fmt.Sprintf("At least one certificate expires within %d days", cc.CriticalDays)
+
+		if untrusted {
+			output = "At least one certificate is not trusted"
+		}
+	}
+
+	return &Result{
+		Status:  rc,
+		Summary: output,
+		Items:   items,
+		Perfdata: check.PerfdataList{
+			{Label: "certificates", Value: len(states)},
+			{Label: "min_remaining_days", Value: minRemainingDays, Warn: warn, Crit: crit},
+		},
+	}, nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type CertificatesTest struct {
+	name     string
+	server   *httptest.Server
+	args     []string
+	expected string
+}
+
+func TestCertificatesCmd(t *testing.T) {
+	tests := []CertificatesTest{
+		{
+			name: "certificates-ok",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"path":"certs/http.p12","format":"PKCS12","alias":"http","subject_dn":"CN=node-1","serial_number":"a1b2","has_private_key":true,"expiry":"2100-01-01T00:00:00.000Z"},{"path":"certs/transport.p12","format":"PKCS12","alias":"transport","subject_dn":"CN=node-1","serial_number":"c3d4","has_private_key":true,"expiry":"2100-01-01T00:00:00.000Z"}]`))
+			})),
+			args:     []string{"run", "../main.go", "certificates"},
+			expected: "[OK] - All 2 certificates are valid for at least 30 days \n \\_[OK] certs/http.p12 (CN=node-1): expires in ",
+		},
+		{
+			name: "certificates-expired",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"path":"certs/http.p12","format":"PKCS12","alias":"http","subject_dn":"CN=node-1","serial_number":"a1b2","has_private_key":true,"expiry":"2100-01-01T00:00:00.000Z"},{"path":"certs/transport.p12","format":"PKCS12","alias":"transport","subject_dn":"CN=node-1","serial_number":"c3d4","has_private_key":true,"expiry":"2020-07-06T21:55:18.129Z"}]`))
+			})),
+			args:     []string{"run", "../main.go", "certificates"},
+			expected: "[CRITICAL] - At least one certificate expires within 7 days",
+		},
+		{
+			name: "certificates-expired-details",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"path":"certs/transport.p12","format":"PKCS12","alias":"transport","subject_dn":"CN=node-1","serial_number":"c3d4","has_private_key":true,"expiry":"2020-07-06T21:55:18.129Z"}]`))
+			})),
+			args:     []string{"run", "../main.go", "certificates"},
+			expected: "\\_[CRITICAL] certs/transport.p12 (CN=node-1): expired ",
+		},
+		{
+			name: "certificates-none",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[]`))
+			})),
+			args:     []string{"run", "../main.go", "certificates"},
+			expected: "[UNKNOWN] - No certificates found",
+		},
+		{
+			name: "certificates-presented-without-tls",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{}`))
+			})),
+			args:     []string{"run", "../main.go", "certificates", "--api=false", "--presented"},
+			expected: "does not use TLS",
+		},
+		{
+			name: "certificates-presented",
+			server: httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{}`))
+			})),
+			args:     []string{"run", "../main.go", "certificates", "--api=false", "--presented", "--insecure"},
+			expected: "[OK] - All 1 certificates are valid for at least 30 days \n \\_[OK] presented by 127.0.0.1:",
+		},
+		{
+			name: "certificates-presented-untrusted",
+			server: httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})),
+			args:     []string{"run", "../main.go", "certificates", "--api=false", "--presented"},
+			expected: "[CRITICAL] - At least one certificate is not trusted \n \\_[CRITICAL] presented by 127.0.0.1:",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			cmd := exec.Command("go", append(test.args, "--hostname", test.server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.Contains(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+
+		})
+	}
+}
+
+func TestCertificatesCmd_PresentedWithCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", "../main.go", "certificates", "--api=false", "--presented",
+		"--ca-file", caFile, "--hostname", server.URL)
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[OK] - All 1 certificates are valid for at least 30 days \n \\_[OK] presented by 127.0.0.1:"
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
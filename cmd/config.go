@@ -1,28 +1,51 @@
 package cmd
 
 import (
+	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NETWAYS/check_elasticsearch/internal/client"
 	"github.com/NETWAYS/go-check"
 	checkhttpconfig "github.com/NETWAYS/go-check-network/http/config"
+	"github.com/spf13/pflag"
 )
 
+// envPrefix is the prefix of the environment variables of the flags
+const envPrefix = "CHECK_ELASTICSEARCH_"
+
 type Config struct {
-	Hostnames []string
-	Bearer    string `env:"CHECK_ELASTICSEARCH_BEARER"`
-	CAFile    string `env:"CHECK_ELASTICSEARCH_CA_FILE"`
-	CertFile  string `env:"CHECK_ELASTICSEARCH_CERT_FILE"`
-	KeyFile   string `env:"CHECK_ELASTICSEARCH_KEY_FILE"`
-	Username  string `env:"CHECK_ELASTICSEARCH_USERNAME"`
-	Password  string `env:"CHECK_ELASTICSEARCH_PASSWORD"`
-	Insecure  bool
+	Hostnames    []string
+	Bearer       string `env:"CHECK_ELASTICSEARCH_BEARER"`
+	BearerFile   string
+	APIKey       string `env:"CHECK_ELASTICSEARCH_API_KEY"`
+	APIKeyFile   string
+	CAFile       string `env:"CHECK_ELASTICSEARCH_CA_FILE"`
+	CertFile     string `env:"CHECK_ELASTICSEARCH_CERT_FILE"`
+	KeyFile      string `env:"CHECK_ELASTICSEARCH_KEY_FILE"`
+	Username     string `env:"CHECK_ELASTICSEARCH_USERNAME"`
+	Password     string `env:"CHECK_ELASTICSEARCH_PASSWORD"`
+	PasswordFile string
+	Insecure     bool `env:"CHECK_ELASTICSEARCH_INSECURE"`
+	// Retries and failover behaviour of the client
+	Retries         int
+	RetryBackoff    time.Duration
+	FailoverStatus  []int
+	FailoverWarning bool
+	Sniff           bool
+	SniffRoles      []string
+	// SkipProductCheck disables the verification that the server is Elasticsearch
+	SkipProductCheck bool
 }
 
 // LoadFromEnv can be used to load struct values from 'env' tags.
@@ -31,7 +54,15 @@ type Config struct {
 //	type Config struct {
 //		Token    string `env:"BEARER_TOKEN"`
 //	}
-func loadFromEnv(config any) {
+//
+// The value can also be read from a file given in the same variable
+// with a '_FILE' suffix (e.g. BEARER_TOKEN_FILE), for example to
+// use mounted secrets. The variable without the suffix takes precedence.
+//
+// Variables are skipped when their flag is set on the CLI, the flag is
+// the name of the variable without the prefix (e.g. --ca-file for
+// CHECK_ELASTICSEARCH_CA_FILE) or the flag of its secret file.
+func loadFromEnv(config any, flags *pflag.FlagSet) error {
 	configValue := reflect.ValueOf(config).Elem()
 	configType := configValue.Type()
 
@@ -44,30 +75,99 @@ func loadFromEnv(config any) {
 			continue
 		}
 
+		if flags != nil {
+			flag := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(tag, envPrefix)), "_", "-")
+			if flags.Changed(flag) || flags.Changed(flag+"-file") {
+				continue
+			}
+		}
+
 		envValue := os.Getenv(tag)
 
+		if envValue == "" && os.Getenv(tag+"_FILE") != "" {
+			secret, err := readSecretFile(os.Getenv(tag + "_FILE"))
+			if err != nil {
+				return fmt.Errorf("could not load %s_FILE: %w", tag, err)
+			}
+
+			envValue = secret
+		}
+
 		if envValue == "" {
 			continue
 		}
 
 		// Potential for addding different types
-		// nolint: exhaustive, gocritic
+		// nolint: exhaustive
 		switch field.Type.Kind() {
 		case reflect.String:
 			configValue.Field(i).SetString(envValue)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(envValue)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %s", tag, envValue)
+			}
+
+			configValue.Field(i).SetBool(b)
+		}
+	}
+
+	return nil
+}
+
+// readSecretFile returns the content of a file containing a secret,
+// without trailing line breaks
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// loadSecretFiles sets the secrets that are given as a file,
+// these take precedence over the plain values
+func (c *Config) loadSecretFiles() error {
+	secrets := []struct {
+		file   string
+		target *string
+	}{
+		{c.PasswordFile, &c.Password},
+		{c.BearerFile, &c.Bearer},
+		{c.APIKeyFile, &c.APIKey},
+	}
+
+	for _, secret := range secrets {
+		if secret.file == "" {
+			continue
 		}
+
+		value, err := readSecretFile(secret.file)
+		if err != nil {
+			return fmt.Errorf("could not read secret file: %w", err)
+		}
+
+		*secret.target = value
 	}
+
+	return nil
 }
 
 var cliConfig Config
 
-func (c *Config) NewClient() *client.Client {
+func (c *Config) NewClient() (*client.Client, error) {
+	err := c.loadSecretFiles()
+	if err != nil {
+		return nil, err
+	}
+
 	urls := make([]*url.URL, 0, len(c.Hostnames))
 
 	for _, host := range c.Hostnames {
 		u, errParse := url.Parse(host)
 		if errParse != nil {
-			check.ExitError(errParse)
+			return nil, fmt.Errorf("invalid value for --hostname: %w", errParse)
 		}
 
 		urls = append(urls, u)
@@ -81,7 +181,7 @@ func (c *Config) NewClient() *client.Client {
 		CertFile:           c.CertFile,
 	})
 	if err != nil {
-		check.ExitError(err)
+		return nil, err
 	}
 
 	var rt http.RoundTripper = &http.Transport{
@@ -92,6 +192,8 @@ func (c *Config) NewClient() *client.Client {
 		}).DialContext,
 		TLSHandshakeTimeout: 10 * time.Second,
 		TLSClientConfig:     tlsConfig,
+		// Keep the connections of concurrent requests, e.g. of the multi command
+		MaxIdleConnsPerHost: 16,
 	}
 
 	// Using a Bearer Token for authentication
@@ -99,14 +201,149 @@ func (c *Config) NewClient() *client.Client {
 		rt = checkhttpconfig.NewAuthorizationCredentialsRoundTripper("Bearer", c.Bearer, rt)
 	}
 
+	// Using an API Key for authentication, either base64 encoded or as id:key
+	if c.APIKey != "" {
+		apiKey := c.APIKey
+		if strings.Contains(apiKey, ":") {
+			apiKey = base64.StdEncoding.EncodeToString([]byte(apiKey))
+		}
+
+		rt = checkhttpconfig.NewAuthorizationCredentialsRoundTripper("ApiKey", apiKey, rt)
+	}
+
 	// Using a BasicAuth for authentication
 	if c.Username != "" {
 		if c.Password == "" {
-			check.ExitError(errors.New("specify the user name and password for server authentication"))
+			return nil, errors.New("specify the user name and password for server authentication")
 		}
 
 		rt = checkhttpconfig.NewBasicAuthRoundTripper(c.Username, c.Password, rt)
 	}
 
-	return client.NewClient(urls, rt)
+	cl := client.NewClient(urls, rt)
+	cl.Retries = c.Retries
+	cl.RetryBackoff = c.RetryBackoff
+	cl.FailoverStatus = c.FailoverStatus
+	cl.Sniff = c.Sniff
+	cl.SniffRoles = c.SniffRoles
+	cl.SkipProductCheck = c.SkipProductCheck
+	cl.TLSConfig = tlsConfig
+
+	return cl, nil
+}
+
+// checkFailover raises the state of the result to WARNING when --failover-warning
+// is set and a node failed before another node answered. The failed nodes are
+// appended to the summary.
+func (c *Config) checkFailover(cl *client.Client, r *Result) {
+	failedNodes := cl.FailedNodes()
+
+	if !c.FailoverWarning || len(failedNodes) == 0 {
+		return
+	}
+
+	failed := make([]string, 0, len(failedNodes))
+
+	for _, ne := range failedNodes {
+		// A node can fail for multiple requests
+		if !slices.Contains(failed, ne.Error()) {
+			failed = append(failed, ne.Error())
+		}
+	}
+
+	r.Status = check.WorstState(r.Status, check.Warning)
+	r.Summary += " (failover, failed nodes: " + strings.Join(failed, ", ") + ")"
+}
+
+// statusCodesValue is a flag with a list of HTTP status codes, like an IntSlice
+// flag that also accepts an empty value to clear the list
+type statusCodesValue struct {
+	value   *[]int
+	changed bool
+}
+
+func newStatusCodesValue(val []int, p *[]int) *statusCodesValue {
+	*p = val
+
+	return &statusCodesValue{value: p}
+}
+
+func (s *statusCodesValue) Set(val string) error {
+	codes := []int{}
+
+	if val != "" {
+		for _, v := range strings.Split(val, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+
+			codes = append(codes, code)
+		}
+	}
+
+	if !s.changed || len(codes) == 0 {
+		*s.value = codes
+	} else {
+		*s.value = append(*s.value, codes...)
+	}
+
+	s.changed = true
+
+	return nil
+}
+
+func (s *statusCodesValue) Type() string {
+	return "intSlice"
+}
+
+func (s *statusCodesValue) String() string {
+	codes := make([]string, 0, len(*s.value))
+	for _, code := range *s.value {
+		codes = append(codes, strconv.Itoa(code))
+	}
+
+	return "[" + strings.Join(codes, ",") + "]"
+}
+
+// timeoutDeadline is the time at which check.HandleTimeout exits the plugin,
+// it is not set for long running commands
+var timeoutDeadline time.Time
+
+// timeoutMargin is the time left between the deadline of the requests and the
+// plugin --timeout, so that a slow request ends with a meaningful error
+func timeoutMargin() time.Duration {
+	t := time.Duration(timeout) * time.Second
+
+	return min(5*time.Second, t/5)
+}
+
+// pluginDeadline returns the end of the plugin --timeout, for long running
+// commands the timeout starts now
+func pluginDeadline() time.Time {
+	if timeoutDeadline.IsZero() {
+		return time.Now().Add(time.Duration(timeout) * time.Second)
+	}
+
+	return timeoutDeadline
+}
+
+// timeoutContext returns the context for the requests of a check. Its deadline
+// is shortly before the plugin --timeout, so that a slow request ends with a
+// meaningful error before check.HandleTimeout exits the plugin. With
+// --submit-icinga another margin is kept for the submission of the result.
+func timeoutContext() (context.Context, context.CancelFunc) {
+	deadline := pluginDeadline().Add(-timeoutMargin())
+
+	if cliIcingaConfig.Submit {
+		deadline = deadline.Add(-timeoutMargin())
+	}
+
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+// submitContext returns the context for the submission of the results, its
+// deadline is the time left until shortly before the plugin --timeout
+func submitContext() (context.Context, context.CancelFunc) {
+	return context.WithDeadline(context.Background(), pluginDeadline().Add(-timeoutMargin()))
 }
@@ -2,7 +2,13 @@ package cmd
 
 import (
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
 )
 
 func TestLoadFromEnv(t *testing.T) {
@@ -15,7 +21,7 @@ func TestLoadFromEnv(t *testing.T) {
 		t.Error("Did not expect error, got: %w", err)
 	}
 
-	loadFromEnv(&c)
+	loadFromEnv(&c, nil)
 
 	if "foobar" != c.Username {
 		t.Error("\nActual: ", c.Username, "\nExpected: ", "foobar")
@@ -24,3 +30,244 @@ func TestLoadFromEnv(t *testing.T) {
 		t.Error("\nActual: ", c.Password, "\nExpected: ", "empty-string")
 	}
 }
+
+func TestLoadFromEnv_APIKey(t *testing.T) {
+	c := Config{}
+
+	err := os.Setenv("CHECK_ELASTICSEARCH_API_KEY", "aWQ6a2V5")
+	defer os.Unsetenv("CHECK_ELASTICSEARCH_API_KEY") // to not impact other tests
+
+	if err != nil {
+		t.Error("Did not expect error, got: %w", err)
+	}
+
+	loadFromEnv(&c, nil)
+
+	if "aWQ6a2V5" != c.APIKey {
+		t.Error("\nActual: ", c.APIKey, "\nExpected: ", "aWQ6a2V5")
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	err := os.WriteFile(path, []byte(`
+profiles:
+  production:
+    hostnames:
+      - https://node1:9200
+      - https://node2:9200
+    username: monitoring
+    password: secret
+    timeout: 60
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := loadProfile(path, "production")
+	if err != nil {
+		t.Error("Did not expect error, got: ", err)
+	}
+
+	if len(p.Hostnames) != 2 || p.Username != "monitoring" || p.Password != "secret" || p.Timeout != 60 {
+		t.Error("\nActual: ", p, "\nExpected: ", "production profile")
+	}
+
+	_, err = loadProfile(path, "staging")
+	if err == nil || !strings.Contains(err.Error(), "profile 'staging' not found") {
+		t.Error("\nActual: ", err, "\nExpected: ", "profile 'staging' not found")
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	c := Config{
+		Hostnames: []string{"http://localhost:9200"},
+		Username:  "from-env",
+		Password:  "from-env",
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringArrayVarP(&c.Hostnames, "hostname", "H", c.Hostnames, "")
+	fs.StringVarP(&c.Username, "username", "U", c.Username, "")
+	fs.StringVarP(&c.Password, "password", "P", c.Password, "")
+
+	err := fs.Parse([]string{"--username", "from-cli"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.applyProfile(&Profile{
+		Hostnames: []string{"https://node1:9200"},
+		Username:  "from-profile",
+		Password:  "from-profile",
+	}, fs)
+
+	if c.Username != "from-cli" {
+		t.Error("\nActual: ", c.Username, "\nExpected: ", "from-cli")
+	}
+
+	if c.Password != "from-profile" {
+		t.Error("\nActual: ", c.Password, "\nExpected: ", "from-profile")
+	}
+
+	if len(c.Hostnames) != 1 || c.Hostnames[0] != "https://node1:9200" {
+		t.Error("\nActual: ", c.Hostnames, "\nExpected: ", "https://node1:9200")
+	}
+}
+
+func TestLoadFromEnv_File(t *testing.T) {
+	c := Config{}
+
+	path := filepath.Join(t.TempDir(), "password")
+
+	err := os.WriteFile(path, []byte("secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CHECK_ELASTICSEARCH_PASSWORD_FILE", path)
+	t.Setenv("CHECK_ELASTICSEARCH_INSECURE", "true")
+
+	err = loadFromEnv(&c, nil)
+	if err != nil {
+		t.Error("Did not expect error, got: ", err)
+	}
+
+	if "secret" != c.Password {
+		t.Error("\nActual: ", c.Password, "\nExpected: ", "secret")
+	}
+	if !c.Insecure {
+		t.Error("\nActual: ", c.Insecure, "\nExpected: ", true)
+	}
+}
+
+func TestLoadFromEnv_InvalidBool(t *testing.T) {
+	c := Config{}
+
+	t.Setenv("CHECK_ELASTICSEARCH_INSECURE", "maybe")
+
+	err := loadFromEnv(&c, nil)
+	if err == nil || err.Error() != "invalid value for CHECK_ELASTICSEARCH_INSECURE: maybe" {
+		t.Error("\nActual: ", err, "\nExpected: ", "invalid value for CHECK_ELASTICSEARCH_INSECURE: maybe")
+	}
+}
+
+func TestLoadFromEnv_FlagsTakePrecedence(t *testing.T) {
+	c := Config{}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringVar(&c.Username, "username", "", "")
+	fs.StringVar(&c.PasswordFile, "password-file", "", "")
+
+	err := fs.Parse([]string{"--username", "from-cli", "--password-file", "/run/secrets/password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CHECK_ELASTICSEARCH_USERNAME", "from-env")
+	// The missing file is not read, since the password is given as file on the CLI
+	t.Setenv("CHECK_ELASTICSEARCH_PASSWORD_FILE", "/nonexistent/password")
+	t.Setenv("CHECK_ELASTICSEARCH_CA_FILE", "/etc/ssl/ca.pem")
+
+	err = loadFromEnv(&c, fs)
+	if err != nil {
+		t.Error("Did not expect error, got: ", err)
+	}
+
+	if c.Username != "from-cli" {
+		t.Error("\nActual: ", c.Username, "\nExpected: ", "from-cli")
+	}
+
+	if c.CAFile != "/etc/ssl/ca.pem" {
+		t.Error("\nActual: ", c.CAFile, "\nExpected: ", "/etc/ssl/ca.pem")
+	}
+}
+
+func TestLoadFromEnv_Help(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "health", "--help")
+	cmd.Env = append(os.Environ(), "CHECK_ELASTICSEARCH_INSECURE=maybe")
+
+	out, _ := cmd.CombinedOutput()
+
+	if !strings.Contains(string(out), "Usage:") {
+		t.Error("\nActual: ", string(out), "\nExpected: ", "Usage:")
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+
+	err := os.WriteFile(path, []byte("from-file\r\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{Bearer: "from-env", BearerFile: path}
+
+	err = c.loadSecretFiles()
+	if err != nil {
+		t.Error("Did not expect error, got: ", err)
+	}
+
+	if "from-file" != c.Bearer {
+		t.Error("\nActual: ", c.Bearer, "\nExpected: ", "from-file")
+	}
+
+	c = Config{APIKeyFile: filepath.Join(t.TempDir(), "missing")}
+
+	err = c.loadSecretFiles()
+	if err == nil || !strings.Contains(err.Error(), "could not read secret file") {
+		t.Error("\nActual: ", err, "\nExpected: ", "could not read secret file")
+	}
+}
+
+func TestTimeoutContext(t *testing.T) {
+	defer func(t int) { timeout = t }(timeout)
+
+	testcases := map[int]time.Duration{
+		60: 55 * time.Second,
+		10: 8 * time.Second,
+		1:  800 * time.Millisecond,
+	}
+
+	for seconds, expected := range testcases {
+		timeout = seconds
+
+		ctx, cancel := timeoutContext()
+
+		deadline, _ := ctx.Deadline()
+		actual := time.Until(deadline).Round(100 * time.Millisecond)
+
+		cancel()
+
+		if actual != expected {
+			t.Errorf("timeout %d: expected a deadline in %s, got %s", seconds, expected, actual)
+		}
+	}
+}
+
+func TestSubmitContext(t *testing.T) {
+	defer func(t int, d time.Time, s bool) { timeout, timeoutDeadline, cliIcingaConfig.Submit = t, d, s }(timeout, timeoutDeadline, cliIcingaConfig.Submit)
+
+	// The check started 20 seconds ago
+	timeout = 60
+	timeoutDeadline = time.Now().Add(40 * time.Second)
+	cliIcingaConfig.Submit = true
+
+	ctx, cancel := timeoutContext()
+	defer cancel()
+
+	deadline, _ := ctx.Deadline()
+	if actual := time.Until(deadline).Round(100 * time.Millisecond); actual != 30*time.Second {
+		t.Errorf("expected the check to keep a margin for the submission, got a deadline in %s", actual)
+	}
+
+	submitCtx, submitCancel := submitContext()
+	defer submitCancel()
+
+	deadline, _ = submitCtx.Deadline()
+	if actual := time.Until(deadline).Round(100 * time.Millisecond); actual != 35*time.Second {
+		t.Errorf("expected the submission to end before the plugin timeout, got a deadline in %s", actual)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	es "github.com/NETWAYS/check_elasticsearch/internal/elasticsearch"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// DataStreamConfig stores the CLI parameters.
+type DataStreamConfig struct {
+	Name             string
+	MaxAgeWarning    time.Duration
+	MaxAgeCritical   time.Duration
+	RequireLifecycle bool
+}
+
+var cliDataStreamConfig DataStreamConfig
+
+var dataStreamCmd = &cobra.Command{
+	Use:   "datastream",
+	Short: "Checks the health and rollover of Elasticsearch data streams",
+	Long: `Checks the health and rollover of Elasticsearch data streams
+
+The status of a data stream is the health of its backing indices:
+	green = OK
+	yellow = WARNING
+	red = CRITICAL
+
+The age of the write index is the time since its creation, i.e. since the last
+rollover. When it exceeds --max-age-warning or --max-age-critical the data
+stream has not rolled over, e.g. because the ILM policy is stuck.
+
+On OpenSearch the ISM policy of the write index is shown instead of the ILM policy.`,
+	Example: `
+$ check_elasticsearch datastream --name "logs-*" --max-age-warning 36h --max-age-critical 48h
+[WARNING] - 1 of 2 data streams are not OK
+ \_[OK] logs-app-default is green, 12 backing indices, ILM policy logs, write index .ds-logs-app-default-2024.01.12-000012 created 10h5m ago
+ \_[WARNING] logs-web-default is green, 3 backing indices, ILM policy logs, write index .ds-logs-web-default-2024.01.10-000003 created 1d16h ago
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliDataStreamConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dataStreamCmd)
+
+	fs := dataStreamCmd.Flags()
+	cliDataStreamConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (dc *DataStreamConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&dc.Name, "name", "*",
+		"Comma-separated list of data streams to retrieve. Wildcard (*) expressions are supported")
+	fs.DurationVar(&dc.MaxAgeWarning, "max-age-warning", 0,
+		"Warning threshold for the age of the write index (e.g. 36h). If not set the age is not checked")
+	fs.DurationVar(&dc.MaxAgeCritical, "max-age-critical", 0,
+		"Critical threshold for the age of the write index (e.g. 48h). If not set the age is not checked")
+	fs.BoolVar(&dc.RequireLifecycle, "require-lifecycle", false,
+		"Return WARNING for data streams without ILM policy, data stream lifecycle or ISM policy (OpenSearch)")
+}
+
+func (dc *DataStreamConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		items    []Item
+		perfList check.PerfdataList
+	)
+
+	r, err := c.DataStreams(ctx, dc.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.DataStreams) == 0 {
+		return &Result{Status: check.Unknown, Summary: "No data streams found matching " + dc.Name}, nil
+	}
+
+	// The last backing index is the write index
+	writeIndices := make([]string, 0, len(r.DataStreams))
+
+	for _, ds := range r.DataStreams {
+		if len(ds.Indices) > 0 {
+			writeIndices = append(writeIndices, ds.Indices[len(ds.Indices)-1].IndexName)
+		}
+	}
+
+	created, err := c.IndexCreationDates(ctx, writeIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	ismPolicies, err := dc.ismPolicies(ctx, c, r.DataStreams)
+	if err != nil {
+		return nil, err
+	}
+
+	var ageWarn, ageCrit *check.Threshold
+
+	if dc.MaxAgeWarning > 0 {
+		ageWarn = &check.Threshold{Upper: dc.MaxAgeWarning.Seconds()}
+	}
+
+	if dc.MaxAgeCritical > 0 {
+		ageCrit = &check.Threshold{Upper: dc.MaxAgeCritical.Seconds()}
+	}
+
+	states := make([]check.Status, 0, len(r.DataStreams))
+
+	for _, ds := range r.DataStreams {
+		color := strings.ToLower(ds.Status)
+		state := colorToStatus(color)
+
+		output := fmt.Sprintf("%s is %s, %d backing indices", ds.Name, color, len(ds.Indices))
+
+		switch {
+		case ds.ILMPolicy != "":
+			output += ", ILM policy " + ds.ILMPolicy
+		case hasLifecycle(ds):
+			output += ", data stream lifecycle"
+		case len(ds.Indices) > 0 && ismPolicies[ds.Indices[len(ds.Indices)-1].IndexName] != "":
+			output += ", ISM policy " + ismPolicies[ds.Indices[len(ds.Indices)-1].IndexName]
+		default:
+			output += ", no lifecycle"
+
+			if dc.RequireLifecycle {
+				state = max(state, check.Warning)
+			}
+		}
+
+		prefix := "datastreams." + ds.Name + "."
+
+		perfList.Add(&check.Perfdata{Label: prefix + "backing_indices", Value: len(ds.Indices)})
+
+		if len(ds.Indices) > 0 {
+			writeIndex := ds.Indices[len(ds.Indices)-1].IndexName
+
+			if creation, ok := created[writeIndex]; ok {
+				age := time.Since(creation)
+
+				state = max(state, thresholdState(age.Seconds(), ageWarn, ageCrit))
+				output += fmt.Sprintf(", write index %s created %s ago", writeIndex, formatAge(age))
+
+				perfList.Add(&check.Perfdata{Label: prefix + "write_index_age", Value: math.Round(age.Seconds()),
+					Uom: "s", Warn: ageWarn, Crit: ageCrit})
+			}
+		}
+
+		states = append(states, state)
+		items = append(items, Item{Status: state, Output: output})
+	}
+
+	return &Result{
+		Status:   check.WorstState(states...),
+		Summary:  statesSummary(states, "data streams"),
+		Items:    items,
+		Perfdata: perfList,
+	}, nil
+}
+
+// ismPolicies returns the OpenSearch ISM policies of the write indices of the data
+// streams without ILM policy or data stream lifecycle, which are not used by OpenSearch
+func (dc *DataStreamConfig) ismPolicies(ctx context.Context, c *client.Client, dataStreams []es.DataStream) (map[string]string, error) {
+	var writeIndices []string
+
+	for _, ds := range dataStreams {
+		if ds.ILMPolicy == "" && !hasLifecycle(ds) && len(ds.Indices) > 0 {
+			writeIndices = append(writeIndices, ds.Indices[len(ds.Indices)-1].IndexName)
+		}
+	}
+
+	if len(writeIndices) == 0 {
+		return nil, nil
+	}
+
+	// Without the distribution the data streams are treated as Elasticsearch
+	if openSearch, _ := c.IsOpenSearch(ctx); !openSearch {
+		return nil, nil
+	}
+
+	return c.ISMPolicies(ctx, writeIndices)
+}
+
+// hasLifecycle returns true when the data stream lifecycle is enabled
+func hasLifecycle(ds es.DataStream) bool {
+	return ds.Lifecycle != nil && (ds.Lifecycle.Enabled == nil || *ds.Lifecycle.Enabled)
+}
+
+// formatAge returns the duration in days and hours or hours and minutes, e.g. 1d16h
+func formatAge(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+
+	if days > 0 {
+		return fmt.Sprintf("%dd%dh", days, hours)
+	}
+
+	return fmt.Sprintf("%dh%dm", hours, int(d.Minutes())%60)
+}
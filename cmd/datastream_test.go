@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataStream_ConnectionRefused(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "datastream", "--hostname", "http://localhost:9999")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - could not fetch data streams: no node answered successfully: http://localhost:9999: "
+
+	if !strings.Contains(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestDataStreamCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+
+		switch r.URL.Path {
+		case "/_data_stream/*":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data_streams":[` +
+				`{"name":"logs-app","generation":2,"status":"GREEN","template":"logs","ilm_policy":"logs","indices":[{"index_name":".ds-logs-app-000001"},{"index_name":".ds-logs-app-000002"}]},` +
+				`{"name":"logs-web","generation":1,"status":"YELLOW","template":"logs","indices":[{"index_name":".ds-logs-web-000001"}]}]}`))
+		case "/_data_stream/none":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data_streams":[]}`))
+		case "/.ds-logs-app-000002,.ds-logs-web-000001/_settings/index.creation_date":
+			now := time.Now()
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{".ds-logs-app-000002":{"settings":{"index.creation_date":"%d"}},".ds-logs-web-000001":{"settings":{"index.creation_date":"%d"}}}`,
+				now.Add(-(10*time.Hour + 5*time.Minute + 30*time.Second)).UnixMilli(),
+				now.Add(-(40*time.Hour + 30*time.Minute)).UnixMilli())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "datastream-yellow",
+			args: []string{"run", "../main.go", "datastream"},
+			expected: "[WARNING] - 1 of 2 data streams are not OK \n" +
+				" \\_[OK] logs-app is green, 2 backing indices, ILM policy logs, write index .ds-logs-app-000002 created 10h5m ago\n" +
+				" \\_[WARNING] logs-web is yellow, 1 backing indices, no lifecycle, write index .ds-logs-web-000001 created 1d16h ago|" +
+				"datastreams.logs-app.backing_indices=2 datastreams.logs-app.write_index_age=",
+		},
+		{
+			name: "datastream-max-age",
+			args: []string{"run", "../main.go", "datastream", "--max-age-warning", "12h", "--max-age-critical", "36h"},
+			expected: "[CRITICAL] - 1 of 2 data streams are not OK \n" +
+				" \\_[OK] logs-app is green, 2 backing indices, ILM policy logs, write index .ds-logs-app-000002 created 10h5m ago\n" +
+				" \\_[CRITICAL] logs-web is yellow, 1 backing indices, no lifecycle, write index .ds-logs-web-000001 created 1d16h ago|",
+		},
+		{
+			name:     "datastream-not-found",
+			args:     []string{"run", "../main.go", "datastream", "--name", "none"},
+			expected: "[UNKNOWN] - No data streams found matching none|\nexit status 3\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := exec.Command("go", append(test.args, "--hostname", server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.HasPrefix(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+		})
+	}
+}
+
+func TestDataStreamCmd_OpenSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`{"name":"node-1","version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+		case "/_data_stream/*":
+			w.Write([]byte(`{"data_streams":[` +
+				`{"name":"logs-app","generation":1,"status":"GREEN","template":"logs","indices":[{"index_name":".ds-logs-app-000001"}]},` +
+				`{"name":"logs-web","generation":1,"status":"GREEN","template":"logs","indices":[{"index_name":".ds-logs-web-000001"}]}]}`))
+		case "/.ds-logs-app-000001,.ds-logs-web-000001/_settings/index.creation_date":
+			fmt.Fprintf(w, `{".ds-logs-app-000001":{"settings":{"index.creation_date":"%[1]d"}},".ds-logs-web-000001":{"settings":{"index.creation_date":"%[1]d"}}}`,
+				time.Now().Add(-2*time.Hour).UnixMilli())
+		case "/_plugins/_ism/explain/.ds-logs-app-000001,.ds-logs-web-000001":
+			w.Write([]byte(`{".ds-logs-app-000001":{"index.plugins.index_state_management.policy_id":"rollover","policy_id":"rollover","enabled":true},` +
+				`".ds-logs-web-000001":{"index.plugins.index_state_management.policy_id":null,"enabled":null},"total_managed_indices":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cmd := exec.Command("go", "run", "../main.go", "datastream", "--require-lifecycle", "--hostname", server.URL)
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[WARNING] - 1 of 2 data streams are not OK \n" +
+		" \\_[OK] logs-app is green, 1 backing indices, ISM policy rollover, write index .ds-logs-app-000001 created 2h0m ago\n" +
+		" \\_[WARNING] logs-web is green, 1 backing indices, no lifecycle, write index .ds-logs-web-000001 created 2h0m ago|"
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+)
+
+// ExporterConfig stores the CLI parameters.
+type ExporterConfig struct {
+	Listen      string
+	File        string
+	Concurrency int
+}
+
+// defaultExporterChecks are exported when no check file is given
+var defaultExporterChecks = []CheckDefinition{
+	{Check: "health"},
+	{Check: "ingest"},
+	{Check: "snapshot"},
+}
+
+const (
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	// metricPrefix is the prefix of all exported metric names
+	metricPrefix = "elasticsearch_"
+)
+
+var invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+var cliExporterConfig ExporterConfig
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Runs an HTTP server exposing the check metrics in the OpenMetrics format",
+	Long: `Runs an HTTP server exposing the check metrics in the OpenMetrics format
+
+On every scrape of /metrics the checks are run with a new client and their
+state and perfdata are exposed as gauges, with the same semantics as the checks.
+The checks are defined in the same file format as for the multi command, by
+default the health, ingest and snapshot checks are exported.
+
+The --timeout applies to each scrape instead of the whole process.`,
+	Example: `
+$ check_elasticsearch exporter --listen :9210 --file checks.yml
+
+$ curl http://localhost:9210/metrics
+# TYPE elasticsearch_check_state gauge
+elasticsearch_check_state{check="health",type="health"} 0
+# TYPE elasticsearch_health_nodes gauge
+elasticsearch_health_nodes{check="health"} 3
+...
+# EOF
+`,
+	Annotations: map[string]string{annotationNoTimeout: "true"},
+	Run: func(_ *cobra.Command, _ []string) {
+		var (
+			checks []multiCheck
+			err    error
+		)
+
+		if cliExporterConfig.File != "" {
+			checks, err = loadChecks(cliExporterConfig.File)
+		} else {
+			checks, err = parseChecks(defaultExporterChecks)
+		}
+
+		if err != nil {
+			exitError(err)
+		}
+
+		// Invalid client options are reported on startup instead of every scrape
+		_, err = cliConfig.NewClient()
+		if err != nil {
+			exitError(err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", newExporterHandler(checks, &cliConfig, cliExporterConfig.Concurrency))
+
+		server := &http.Server{
+			Addr:              cliExporterConfig.Listen,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		err = server.ListenAndServe()
+		if err != nil {
+			exitError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exporterCmd)
+
+	fs := exporterCmd.Flags()
+	fs.StringVar(&cliExporterConfig.Listen, "listen", ":9210",
+		"Address the HTTP server listens on")
+	fs.StringVarP(&cliExporterConfig.File, "file", "f", "",
+		"File with the check definitions, see the multi command. If not set the health, ingest and snapshot checks are exported")
+	fs.IntVar(&cliExporterConfig.Concurrency, "concurrency", 4,
+		"Maximum number of checks that run at the same time")
+
+	fs.SortFlags = false
+}
+
+// newExporterHandler returns the handler that runs the checks and writes their metrics.
+// Each scrape uses a new client, so that the failed nodes, sniffed nodes and the
+// product check of a scrape do not affect the following scrapes.
+func newExporterHandler(checks []multiCheck, cfg *Config, concurrency int) http.Handler {
+	mc := &MultiConfig{Concurrency: concurrency}
+
+	// Scrapes are run one after another, to limit the load on the cluster
+	var mu sync.Mutex
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		ctx, cancel := timeoutContext()
+		defer cancel()
+
+		var (
+			results     []*Result
+			failedNodes int
+		)
+
+		c, err := cfg.NewClient()
+		if err != nil {
+			// E.g. a secret file that was removed, the checks are UNKNOWN
+			results = make([]*Result, len(checks))
+			for i := range results {
+				results[i] = &Result{Status: check.Unknown, Summary: err.Error()}
+			}
+		} else {
+			defer c.Client.CloseIdleConnections()
+
+			results = mc.runChecks(ctx, c, checks)
+			failedNodes = len(c.FailedNodes())
+		}
+
+		w.Header().Set("Content-Type", openMetricsContentType)
+		_, _ = w.Write([]byte(openMetrics(checks, results, failedNodes)))
+	})
+}
+
+// metricFamily is a metric name with its samples
+type metricFamily struct {
+	name    string
+	unit    string
+	samples []string
+}
+
+// openMetrics formats the states and perfdata of the checks in the OpenMetrics text format
+func openMetrics(checks []multiCheck, results []*Result, failedNodes int) string {
+	var families []*metricFamily
+
+	add := func(name, unit string, labels [][2]string, value string) {
+		i := slices.IndexFunc(families, func(f *metricFamily) bool { return f.name == name })
+		if i < 0 {
+			families = append(families, &metricFamily{name: name, unit: unit})
+			i = len(families) - 1
+		}
+
+		pairs := make([]string, 0, len(labels))
+		for _, l := range labels {
+			pairs = append(pairs, l[0]+`="`+escapeLabelValue(l[1])+`"`)
+		}
+
+		families[i].samples = append(families[i].samples, name+"{"+strings.Join(pairs, ",")+"} "+value)
+	}
+
+	for i, mcheck := range checks {
+		checkLabel := [2]string{"check", mcheck.name}
+
+		add(metricPrefix+"check_state", "", [][2]string{checkLabel, {"type", mcheck.check}},
+			fmt.Sprintf("%d", results[i].Status))
+
+		for _, p := range results[i].Perfdata {
+			value, ok := metricValue(p)
+			if !ok {
+				continue
+			}
+
+			name, item := metricName(mcheck.check, p.Label)
+			labels := [][2]string{checkLabel}
+
+			if item != "" {
+				labels = append(labels, [2]string{"name", item})
+			}
+
+			var unit string
+
+			switch p.Uom {
+			case "s":
+				unit = "seconds"
+			case "B":
+				unit = "bytes"
+			}
+
+			if unit != "" {
+				name += "_" + unit
+			}
+
+			add(name, unit, labels, value)
+		}
+	}
+
+	var out strings.Builder
+
+	for _, f := range families {
+		out.WriteString("# TYPE " + f.name + " gauge\n")
+
+		if f.unit != "" {
+			out.WriteString("# UNIT " + f.name + " " + f.unit + "\n")
+		}
+
+		for _, sample := range f.samples {
+			out.WriteString(sample + "\n")
+		}
+	}
+
+	out.WriteString("# TYPE " + metricPrefix + "failed_nodes gauge\n")
+	fmt.Fprintf(&out, "%sfailed_nodes %d\n", metricPrefix, failedNodes)
+	out.WriteString("# EOF\n")
+
+	return out.String()
+}
+
+// metricName returns the metric name of a perfdata label. Labels of items in the
+// form prefix.item.field (e.g. pipelines.mypipeline.failed) become the metric
+// prefix_field with the item as name label.
+func metricName(checkType, label string) (name, item string) {
+	first := strings.Index(label, ".")
+	last := strings.LastIndex(label, ".")
+
+	if first >= 0 && first != last {
+		item = label[first+1 : last]
+		label = label[:first] + "_" + label[last+1:]
+	}
+
+	name = metricPrefix + checkType + "_" + label
+
+	return invalidMetricChars.ReplaceAllString(name, "_"), item
+}
+
+// metricValue formats the value of a perfdata, invalid perfdata
+// is omitted like in the plugin output
+func metricValue(p *check.Perfdata) (string, bool) {
+	if !validPerfdata(p) {
+		return "", false
+	}
+
+	switch v := p.Value.(type) {
+	case float64:
+		return check.FormatFloat(v), true
+	case float32:
+		return check.FormatFloat(float64(v)), true
+	default:
+		return fmt.Sprintf("%d", v), true
+	}
+}
+
+// escapeLabelValue escapes a label value as required by the OpenMetrics format
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExporterHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/_cluster/health"):
+			w.Write([]byte(`{"cluster_name":"test","status":"yellow","number_of_nodes":3,"number_of_data_nodes":2,"active_primary_shards":5,"active_shards":9}`))
+		case strings.HasPrefix(r.URL.Path, "/_nodes/stats"):
+			w.Write([]byte(`{"nodes":{"node1":{"ingest":{"pipelines":{"my\"pipeline":{"count":10,"current":3,"failed":5}}}}}}`))
+		default:
+			w.Write([]byte(`{"snapshots":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	checks, err := parseChecks(defaultExporterChecks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Hostnames: []string{server.URL}}
+
+	rec := httptest.NewRecorder()
+	newExporterHandler(checks, &cfg, 2).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	resp := rec.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.Header.Get("Content-Type") != openMetricsContentType {
+		t.Errorf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	expected := `# TYPE elasticsearch_check_state gauge
+elasticsearch_check_state{check="health",type="health"} 1
+elasticsearch_check_state{check="ingest",type="ingest"} 0
+elasticsearch_check_state{check="snapshot",type="snapshot"} 3
+# TYPE elasticsearch_health_nodes gauge
+elasticsearch_health_nodes{check="health"} 3
+# TYPE elasticsearch_health_data_nodes gauge
+elasticsearch_health_data_nodes{check="health"} 2
+# TYPE elasticsearch_health_active_primary_shards gauge
+elasticsearch_health_active_primary_shards{check="health"} 5
+# TYPE elasticsearch_health_active_shards gauge
+elasticsearch_health_active_shards{check="health"} 9
+# TYPE elasticsearch_ingest_pipelines_failed gauge
+elasticsearch_ingest_pipelines_failed{check="ingest",name="my\"pipeline"} 5
+# TYPE elasticsearch_ingest_pipelines_count gauge
+elasticsearch_ingest_pipelines_count{check="ingest",name="my\"pipeline"} 10
+# TYPE elasticsearch_ingest_pipelines_current gauge
+elasticsearch_ingest_pipelines_current{check="ingest",name="my\"pipeline"} 3
+# TYPE elasticsearch_snapshot_snapshots gauge
+elasticsearch_snapshot_snapshots{check="snapshot"} 0
+# TYPE elasticsearch_snapshot_snapshots_success gauge
+elasticsearch_snapshot_snapshots_success{check="snapshot"} 0
+# TYPE elasticsearch_snapshot_snapshots_partial gauge
+elasticsearch_snapshot_snapshots_partial{check="snapshot"} 0
+# TYPE elasticsearch_snapshot_snapshots_failed gauge
+elasticsearch_snapshot_snapshots_failed{check="snapshot"} 0
+# TYPE elasticsearch_snapshot_snapshots_in_progress gauge
+elasticsearch_snapshot_snapshots_in_progress{check="snapshot"} 0
+# TYPE elasticsearch_failed_nodes gauge
+elasticsearch_failed_nodes 0
+# EOF
+`
+
+	if string(body) != expected {
+		t.Error("\nActual: ", string(body), "\nExpected: ", expected)
+	}
+}
+
+func TestExporterHandler_RecoversFromClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_name":"test","status":"green"}`))
+	}))
+	defer server.Close()
+
+	checks, err := parseChecks([]CheckDefinition{{Check: "health"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	cfg := Config{Hostnames: []string{server.URL}, Username: "elastic", PasswordFile: passwordFile}
+	handler := newExporterHandler(checks, &cfg, 1)
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		body, _ := io.ReadAll(rec.Result().Body)
+
+		return string(body)
+	}
+
+	// The secret file does not exist yet
+	if actual := scrape(); !strings.Contains(actual, `elasticsearch_check_state{check="health",type="health"} 3`) {
+		t.Error("\nActual: ", actual, "\nExpected: UNKNOWN health check")
+	}
+
+	err = os.WriteFile(passwordFile, []byte("secret\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := scrape(); !strings.Contains(actual, `elasticsearch_check_state{check="health",type="health"} 0`) {
+		t.Error("\nActual: ", actual, "\nExpected: OK health check")
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	tests := []struct {
+		check string
+		label string
+		name  string
+		item  string
+	}{
+		{"health", "active_shards", "elasticsearch_health_active_shards", ""},
+		{"health-report", "indicators.green", "elasticsearch_health_report_indicators_green", ""},
+		{"ingest", "pipelines.logs.v1.failed", "elasticsearch_ingest_pipelines_failed", "logs.v1"},
+	}
+
+	for _, test := range tests {
+		name, item := metricName(test.check, test.label)
+
+		if name != test.name || item != test.item {
+			t.Errorf("metricName(%s, %s) = %s, %s, expected %s, %s", test.check, test.label, name, item, test.name, test.item)
+		}
+	}
+}
+
+func TestExporter_InvalidChecksJSON(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "exporter", "--output", "json", "--file", "/nonexistent/checks.yml")
+	out, _ := cmd.Output()
+
+	actual := string(out)
+	expected := `{"state":"UNKNOWN","exit_code":3,"summary":"could not read check file: open /nonexistent/checks.yml: no such file or directory","items":[],"metrics":[]}`
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// icinga2Template is the name of the CheckCommand template with the global flags
+	icinga2Template = "elasticsearch-netways"
+	// icinga2VarPrefix is the prefix of the custom variables of the arguments
+	icinga2VarPrefix = "elasticsearch_"
+	icinga2Header    = "# Generated by check_elasticsearch generate icinga2, do not edit\n"
+)
+
+// icinga2ExcludedFlags are not useful for active checks
+var icinga2ExcludedFlags = []string{
+	"help",
+	"version",
+	"output",
+	"submit-icinga",
+	"icinga-url",
+	"icinga-host",
+	"icinga-service",
+	"icinga-username",
+	"icinga-password",
+	"icinga-ca-file",
+	"icinga-cert-file",
+	"icinga-key-file",
+	"icinga-insecure",
+}
+
+var generateBasket bool
+
+var generateCmd = &cobra.Command{
+	Use:    "generate",
+	Short:  "Generates configuration from the commands of the plugin",
+	Hidden: true,
+	Run:    Help,
+}
+
+var generateIcinga2Cmd = &cobra.Command{
+	Use:   "icinga2",
+	Short: "Generates the Icinga 2 CheckCommand definitions",
+	Long: `Generates the Icinga 2 CheckCommand definitions
+
+A template contains the global flags and each check command is a CheckCommand
+importing the template. With --basket an Icinga Director basket is generated.`,
+	Example: `
+$ check_elasticsearch generate icinga2 > contrib/icinga2-commands.conf
+$ check_elasticsearch generate icinga2 --basket > contrib/icinga2-basket.json
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		var output string
+
+		if generateBasket {
+			basket, err := generateIcinga2Basket(rootCmd)
+			if err != nil {
+				check.ExitError(err)
+			}
+
+			output = basket
+		} else {
+			output = generateIcinga2Commands(rootCmd)
+		}
+
+		_, _ = os.Stdout.WriteString(output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateIcinga2Cmd)
+
+	generateIcinga2Cmd.Flags().BoolVar(&generateBasket, "basket", false,
+		"Generate an Icinga Director basket instead of the CheckCommand definitions")
+}
+
+// icinga2Argument is an argument of a CheckCommand
+type icinga2Argument struct {
+	Flag        string
+	VarName     string
+	Description string
+	Kind        string
+}
+
+const (
+	argumentValue = "value"
+	argumentBool  = "bool"
+	// argumentBoolTrue is a boolean flag that defaults to true and needs an explicit value to disable it
+	argumentBoolTrue = "bool-true"
+	argumentArray    = "array"
+)
+
+// icinga2Check is a check command with its arguments
+type icinga2Check struct {
+	Name      string
+	Command   string
+	Arguments []icinga2Argument
+}
+
+// icinga2Commands returns the arguments of the global flags and the check commands,
+// hidden and long running commands are skipped
+func icinga2Commands(root *cobra.Command) ([]icinga2Argument, []icinga2Check) {
+	global := icinga2Arguments(root.PersistentFlags(), "")
+
+	var checks []icinga2Check
+
+	for _, cmd := range root.Commands() {
+		if cmd.Hidden || !cmd.IsAvailableCommand() || cmd.Annotations[annotationNoTimeout] != "" {
+			continue
+		}
+
+		checks = append(checks, icinga2Check{
+			Name:      "elasticsearch-" + cmd.Name(),
+			Command:   cmd.Name(),
+			Arguments: icinga2Arguments(cmd.LocalNonPersistentFlags(), cmd.Name()),
+		})
+	}
+
+	return global, checks
+}
+
+// icinga2Arguments returns the arguments of the flags. The variable names contain the
+// command, unless the flag has the name of the command (e.g. elasticsearch_query).
+func icinga2Arguments(fs *pflag.FlagSet, command string) []icinga2Argument {
+	var args []icinga2Argument
+
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || slices.Contains(icinga2ExcludedFlags, f.Name) {
+			return
+		}
+
+		name := f.Name
+		if command != "" && command != f.Name {
+			name = command + "_" + f.Name
+		}
+
+		arg := icinga2Argument{
+			Flag:        "--" + f.Name,
+			VarName:     icinga2VarPrefix + strings.ReplaceAll(name, "-", "_"),
+			Description: f.Usage,
+			Kind:        argumentValue,
+		}
+
+		switch f.Value.Type() {
+		case "bool":
+			arg.Kind = argumentBool
+			if f.DefValue == "true" {
+				arg.Kind = argumentBoolTrue
+			}
+		case "stringArray", "stringSlice", "intSlice":
+			arg.Kind = argumentArray
+		}
+
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "[]" && f.DefValue != "0" && f.DefValue != "0s" {
+			arg.Description += " (default '" + strings.Trim(f.DefValue, "[]") + "')"
+		}
+
+		args = append(args, arg)
+	})
+
+	return args
+}
+
+// generateIcinga2Commands returns the CheckCommand definitions in the Icinga 2 DSL
+func generateIcinga2Commands(root *cobra.Command) string {
+	global, checks := icinga2Commands(root)
+
+	var out strings.Builder
+
+	out.WriteString(icinga2Header + "\n")
+	out.WriteString(`template CheckCommand "` + icinga2Template + `" {` + "\n")
+	out.WriteString(`    command = [ PluginDir + "/check_elasticsearch" ]` + "\n\n")
+	writeIcinga2Arguments(&out, "=", global)
+	out.WriteString("}\n")
+
+	for _, c := range checks {
+		out.WriteString("\n" + `object CheckCommand "` + c.Name + `" {` + "\n")
+		out.WriteString(`    import "` + icinga2Template + `"` + "\n\n")
+		out.WriteString(`    command += [ "` + c.Command + `" ]` + "\n")
+
+		if len(c.Arguments) > 0 {
+			out.WriteString("\n")
+			writeIcinga2Arguments(&out, "+=", c.Arguments)
+		}
+
+		out.WriteString("}\n")
+	}
+
+	return out.String()
+}
+
+func writeIcinga2Arguments(out *strings.Builder, operator string, args []icinga2Argument) {
+	out.WriteString("    arguments " + operator + " {\n")
+
+	for _, arg := range args {
+		out.WriteString("        " + strconv.Quote(arg.Flag) + " = {\n")
+
+		switch arg.Kind {
+		case argumentBool:
+			out.WriteString(`            set_if = "$` + arg.VarName + `$"` + "\n")
+		case argumentBoolTrue:
+			out.WriteString(`            value = "` + arg.Flag + `=$` + arg.VarName + `$"` + "\n")
+			out.WriteString("            skip_key = true\n")
+		case argumentArray:
+			out.WriteString(`            value = "$` + arg.VarName + `$"` + "\n")
+			out.WriteString("            repeat_key = true\n")
+		default:
+			out.WriteString(`            value = "$` + arg.VarName + `$"` + "\n")
+		}
+
+		out.WriteString("            description = " + strconv.Quote(arg.Description) + "\n")
+		out.WriteString("        }\n")
+	}
+
+	out.WriteString("    }\n")
+}
+
+// directorBasket is an Icinga Director basket with the CheckCommands and their data fields
+type directorBasket struct {
+	ExternalCommand map[string]directorCommand   `json:"ExternalCommand"`
+	CommandTemplate map[string]directorCommand   `json:"CommandTemplate"`
+	Datafield       map[string]directorDatafield `json:"Datafield"`
+}
+
+type directorCommand struct {
+	Arguments      map[string]directorArgument `json:"arguments"`
+	Command        string                      `json:"command"`
+	Fields         []directorField             `json:"fields"`
+	MethodsExecute string                      `json:"methods_execute"`
+	ObjectName     string                      `json:"object_name"`
+	ObjectType     string                      `json:"object_type"`
+	Timeout        int                         `json:"timeout,omitempty"`
+}
+
+type directorArgument struct {
+	Description string `json:"description"`
+	RepeatKey   bool   `json:"repeat_key,omitempty"`
+	SetIf       string `json:"set_if,omitempty"`
+	SetIfFormat string `json:"set_if_format,omitempty"`
+	SkipKey     bool   `json:"skip_key,omitempty"`
+	Value       string `json:"value,omitempty"`
+}
+
+type directorField struct {
+	DatafieldID int     `json:"datafield_id"`
+	IsRequired  string  `json:"is_required"`
+	VarFilter   *string `json:"var_filter"`
+}
+
+type directorDatafield struct {
+	Varname     string            `json:"varname"`
+	Caption     string            `json:"caption"`
+	Description string            `json:"description"`
+	Datatype    string            `json:"datatype"`
+	Format      *string           `json:"format"`
+	Settings    map[string]string `json:"settings"`
+	Category    *string           `json:"category"`
+}
+
+// generateIcinga2Basket returns the CheckCommand definitions as Icinga Director basket
+func generateIcinga2Basket(root *cobra.Command) (string, error) {
+	global, checks := icinga2Commands(root)
+
+	basket := directorBasket{
+		ExternalCommand: map[string]directorCommand{},
+		CommandTemplate: map[string]directorCommand{},
+		Datafield:       map[string]directorDatafield{},
+	}
+
+	// addArguments adds the arguments and their data fields to the command
+	addArguments := func(cmd *directorCommand, args []icinga2Argument, setIfFormat string) {
+		for _, arg := range args {
+			da := directorArgument{Description: arg.Description, Value: "$" + arg.VarName + "$"}
+			datatype := "Icinga\\Module\\Director\\DataType\\DataTypeString"
+
+			switch arg.Kind {
+			case argumentBool:
+				da = directorArgument{Description: arg.Description, SetIf: "$" + arg.VarName + "$", SetIfFormat: setIfFormat}
+				datatype = "Icinga\\Module\\Director\\DataType\\DataTypeBoolean"
+			case argumentBoolTrue:
+				da.Value = arg.Flag + "=$" + arg.VarName + "$"
+				da.SkipKey = true
+				datatype = "Icinga\\Module\\Director\\DataType\\DataTypeBoolean"
+			case argumentArray:
+				da.RepeatKey = true
+				datatype = "Icinga\\Module\\Director\\DataType\\DataTypeArray"
+			}
+
+			cmd.Arguments[arg.Flag] = da
+
+			id := len(basket.Datafield) + 1
+			basket.Datafield[strconv.Itoa(id)] = directorDatafield{
+				Varname:     arg.VarName,
+				Caption:     arg.VarName,
+				Description: arg.Description,
+				Datatype:    datatype,
+				Settings:    map[string]string{},
+			}
+
+			cmd.Fields = append(cmd.Fields, directorField{DatafieldID: id, IsRequired: "n"})
+		}
+	}
+
+	template := directorCommand{
+		Arguments:      map[string]directorArgument{},
+		Command:        "PluginDir + /check_elasticsearch",
+		Fields:         []directorField{},
+		MethodsExecute: "PluginCheck",
+		ObjectName:     icinga2Template,
+		ObjectType:     "template",
+	}
+	addArguments(&template, global, "string")
+	basket.CommandTemplate[icinga2Template] = template
+
+	for _, c := range checks {
+		cmd := directorCommand{
+			Arguments:      map[string]directorArgument{},
+			Command:        "check_elasticsearch " + c.Command,
+			Fields:         []directorField{},
+			MethodsExecute: "PluginCheck",
+			ObjectName:     c.Name,
+			ObjectType:     "external_object",
+			Timeout:        60,
+		}
+
+		// External commands contain the arguments of the template as well,
+		// the data fields of the template are not repeated
+		for _, arg := range global {
+			cmd.Arguments[arg.Flag] = template.Arguments[arg.Flag]
+		}
+
+		addArguments(&cmd, c.Arguments, "")
+		basket.ExternalCommand[c.Name] = cmd
+	}
+
+	data, err := json.MarshalIndent(basket, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("could not encode the basket: %w", err)
+	}
+
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateIcinga2_Contrib(t *testing.T) {
+	expected, err := os.ReadFile("../contrib/icinga2-commands.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := generateIcinga2Commands(rootCmd); actual != string(expected) {
+		t.Error("contrib/icinga2-commands.conf is out of date, run: check_elasticsearch generate icinga2")
+	}
+
+	expected, err = os.ReadFile("../contrib/icinga2-basket.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := generateIcinga2Basket(rootCmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual != string(expected) {
+		t.Error("contrib/icinga2-basket.json is out of date, run: check_elasticsearch generate icinga2 --basket")
+	}
+}
+
+func TestIcinga2Arguments(t *testing.T) {
+	args := icinga2Arguments(queryCmd.Flags(), "query")
+
+	expected := map[string]string{
+		"--query": "elasticsearch_query",
+		"--index": "elasticsearch_query_index",
+	}
+
+	for _, arg := range args {
+		if v, ok := expected[arg.Flag]; ok && v != arg.VarName {
+			t.Errorf("expected %s for %s, got %s", v, arg.Flag, arg.VarName)
+		}
+	}
+
+	global, _ := icinga2Commands(rootCmd)
+
+	for _, arg := range global {
+		if arg.Flag == "--insecure" && arg.Kind != argumentBool {
+			t.Error("expected --insecure to be a boolean argument")
+		}
+
+		if strings.HasPrefix(arg.Flag, "--icinga") || arg.Flag == "--output" {
+			t.Errorf("unexpected argument %s", arg.Flag)
+		}
+	}
+}
@@ -1,10 +1,27 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
 	"github.com/NETWAYS/go-check"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// HealthConfig stores the CLI parameters.
+type HealthConfig struct {
+	MultiCluster bool
+	Aggregation  string
+	MinHealthy   int
+}
+
+var cliHealthConfig HealthConfig
+
 var healthCmd = &cobra.Command{
 	Use:   "health",
 	Short: "Checks the health status of an Elasticsearch cluster",
@@ -13,51 +30,212 @@ var healthCmd = &cobra.Command{
 The cluster health status is:
 	green = OK
 	yellow = WARNING
-	red = CRITICAL`,
+	red = CRITICAL
+
+With --multi-cluster each --hostname is a separate cluster. The clusters are
+queried concurrently and their states are aggregated:
+	worst = the worst state of all clusters
+	best = the best state of all clusters
+	--min-healthy N = OK when at least N clusters are healthy, CRITICAL otherwise.
+	                  Healthy clusters are green or yellow, they can serve reads.`,
 	Example: "  check_elasticsearch health --hostname \"https://localhost:9200\" --username \"exampleUser\"  " +
-		"--password \"examplePass\" --insecure",
+		"--password \"examplePass\" --insecure\n" +
+		"  check_elasticsearch health --multi-cluster --min-healthy 1 --hostname \"https://eu:9200\" --hostname \"https://us:9200\"",
 	Run: func(_ *cobra.Command, _ []string) {
-		client := cliConfig.NewClient()
+		runCheck(&cliHealthConfig)
+	},
+}
 
-		health, err := client.Health()
-		if err != nil {
-			check.ExitError(err)
-		}
+func init() {
+	rootCmd.AddCommand(healthCmd)
+	healthCmd.DisableFlagsInUseLine = true
 
-		var rc check.Status
-		// How we map cluster states:
-		// green = OK
-		// yellow = Warning
-		// red = Critical
-		// unknown = Unknown
-		switch health.Status {
-		case "green":
-			rc = check.OK
-		case "yellow":
-			rc = check.Warning
-		case "red":
-			rc = check.Critical
-		default:
-			rc = check.Unknown
-		}
+	fs := healthCmd.Flags()
+	cliHealthConfig.addFlags(fs)
+	fs.SortFlags = false
 
-		var output = "Cluster status unknown"
-		if health.Status != "" {
-			output = "Cluster " + health.ClusterName + " is " + health.Status
-		}
+	healthCmd.MarkFlagsMutuallyExclusive("aggregation", "min-healthy")
+}
 
-		p := check.PerfdataList{
+func (hc *HealthConfig) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&hc.MultiCluster, "multi-cluster", false,
+		"Treat each --hostname as a separate cluster instead of a failover node")
+	fs.StringVar(&hc.Aggregation, "aggregation", "worst",
+		"Aggregation of the cluster states with --multi-cluster (worst, best)")
+	fs.IntVar(&hc.MinHealthy, "min-healthy", 0,
+		"Minimum number of healthy (green or yellow) clusters with --multi-cluster, replaces the --aggregation")
+}
+
+func (hc *HealthConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	if hc.MultiCluster {
+		return hc.runMultiCluster(ctx, c)
+	}
+
+	health, err := c.Health(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var output = "Cluster status unknown"
+	if health.Status != "" {
+		output = "Cluster " + health.ClusterName + " is " + health.Status
+	}
+
+	rc := colorToStatus(health.Status)
+
+	if !health.ClusterManagerDiscovered() {
+		rc = check.Critical
+		output += ", no cluster manager discovered"
+	}
+
+	return &Result{
+		Status:  rc,
+		Summary: output,
+		Perfdata: check.PerfdataList{
 			{Label: "nodes", Value: health.NumberOfNodes},
 			{Label: "data_nodes", Value: health.NumberOfDataNodes},
 			{Label: "active_primary_shards", Value: health.ActivePrimaryShards},
 			{Label: "active_shards", Value: health.ActiveShards},
+		},
+	}, nil
+}
+
+// clusterHealth is the result of one cluster in the multi-cluster mode
+type clusterHealth struct {
+	rc     check.Status
+	color  string
+	output string
+}
+
+// runMultiCluster queries each node of the client as a separate cluster
+// and aggregates their states
+func (hc *HealthConfig) runMultiCluster(ctx context.Context, c *client.Client) (*Result, error) {
+	if hc.Aggregation != "worst" && hc.Aggregation != "best" {
+		return nil, fmt.Errorf("invalid value for --aggregation: %s", hc.Aggregation)
+	}
+
+	if hc.MinHealthy > len(c.URLs) {
+		return nil, errors.New("--min-healthy is greater than the number of clusters")
+	}
+
+	results := make([]clusterHealth, len(c.URLs))
+
+	var wg sync.WaitGroup
+
+	for i, u := range c.URLs {
+		wg.Go(func() {
+			results[i] = fetchClusterHealth(ctx, c.ForURL(u))
+		})
+	}
+
+	wg.Wait()
+
+	var (
+		states []check.Status
+		items  []Item
+		counts = map[string]int{"green": 0, "yellow": 0, "red": 0, "unknown": 0}
+	)
+
+	for _, result := range results {
+		states = append(states, result.rc)
+		counts[result.color]++
+
+		items = append(items, Item{Status: result.rc, Output: result.output})
+	}
+
+	var rc check.Status
+
+	summary := fmt.Sprintf("%d of %d clusters are green", counts["green"], len(results))
+
+	switch {
+	case hc.MinHealthy > 0:
+		// Yellow clusters can serve reads as well
+		healthy := counts["green"] + counts["yellow"]
+
+		rc = check.Critical
+		if healthy >= hc.MinHealthy {
+			rc = check.OK
 		}
 
-		check.ExitWithPerfdata(rc, p, output)
-	},
+		summary = fmt.Sprintf("%d of %d clusters are healthy", healthy, len(results))
+	case hc.Aggregation == "best":
+		rc = bestState(states...)
+	default:
+		rc = check.WorstState(states...)
+	}
+
+	return &Result{
+		Status:  rc,
+		Summary: summary,
+		Items:   items,
+		Perfdata: check.PerfdataList{
+			{Label: "clusters.green", Value: counts["green"]},
+			{Label: "clusters.yellow", Value: counts["yellow"]},
+			{Label: "clusters.red", Value: counts["red"]},
+			{Label: "clusters.unknown", Value: counts["unknown"]},
+		},
+	}, nil
 }
 
-func init() {
-	rootCmd.AddCommand(healthCmd)
-	healthCmd.DisableFlagsInUseLine = true
+// fetchClusterHealth retrieves the health of the cluster behind the single node of the client
+func fetchClusterHealth(ctx context.Context, c *client.Client) clusterHealth {
+	// Credentials are not shown in the output
+	host := c.URLs[0].Redacted()
+
+	health, err := c.Health(ctx)
+	if err != nil {
+		return clusterHealth{rc: check.Unknown, color: "unknown", output: host + ": " + err.Error()}
+	}
+
+	rc := colorToStatus(health.Status)
+
+	color := health.Status
+	if rc == check.Unknown {
+		color = "unknown"
+	}
+
+	output := fmt.Sprintf("Cluster %s is %s (%s)", health.ClusterName, health.Status, host)
+
+	if !health.ClusterManagerDiscovered() {
+		rc = check.Critical
+		output += ", no cluster manager discovered"
+	}
+
+	return clusterHealth{
+		rc:     rc,
+		color:  color,
+		output: output,
+	}
+}
+
+// bestState returns the best of the given states, an UNKNOWN
+// cluster is considered worse than a CRITICAL cluster
+func bestState(states ...check.Status) check.Status {
+	order := []check.Status{check.OK, check.Warning, check.Critical, check.Unknown}
+
+	for _, rc := range order {
+		if slices.Contains(states, rc) {
+			return rc
+		}
+	}
+
+	return check.Unknown
+}
+
+// colorToStatus maps Elasticsearch's status colors to check states:
+// green = OK
+// yellow = Warning
+// red = Critical
+// unknown = Unknown
+func colorToStatus(color string) check.Status {
+	switch color {
+	case "green":
+		return check.OK
+	case "yellow":
+		return check.Warning
+	case "red":
+		return check.Critical
+	default:
+		return check.Unknown
+	}
 }
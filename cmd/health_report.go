@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// HealthReportConfig stores the CLI parameters.
+type HealthReportConfig struct {
+	Indicators        []string
+	ExcludeIndicators []string
+}
+
+const healthReportOutput = "%s is %s: %s"
+
+var cliHealthReportConfig HealthReportConfig
+
+var healthReportCmd = &cobra.Command{
+	Use:   "health-report",
+	Short: "Checks the health report indicators of an Elasticsearch cluster",
+	Long: `Checks the health report indicators of an Elasticsearch cluster (Elasticsearch 8.7+)
+
+Each indicator (e.g. master_is_stable, shards_availability, disk, ilm, slm,
+repository_integrity, shards_capacity) is mapped to a state:
+	green = OK
+	yellow = WARNING
+	red = CRITICAL
+	unknown = UNKNOWN
+
+The worst state of all evaluated indicators is used as exit code.
+If the cluster does not provide the health report API, the cluster health is used instead.`,
+	Example: `
+$ check_elasticsearch health-report
+[OK] - Cluster example health indicators are green
+
+$ check_elasticsearch health-report --indicator disk --indicator shards_availability
+[WARNING] - Cluster example has yellow health indicators
+ \_[OK] disk is green: The cluster has enough available disk space.
+ \_[WARNING] shards_availability is yellow: This cluster has 1 unavailable replica shard.
+    Impact: Searches might be slower than usual. Fewer redundant copies of the data exist on 1 index [example].
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliHealthReportConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(healthReportCmd)
+
+	fs := healthReportCmd.Flags()
+	cliHealthReportConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (hc *HealthReportConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&hc.Indicators, "indicator", []string{},
+		"Name of a health indicator to evaluate. Can be used multiple times. If not set all indicators are evaluated")
+	fs.StringArrayVar(&hc.ExcludeIndicators, "exclude-indicator", []string{},
+		"Name of a health indicator to ignore. Can be used multiple times")
+}
+
+func (hc *HealthReportConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	report, err := c.HealthReport(ctx)
+	if errors.Is(err, client.ErrNotSupported) {
+		return healthReportFallback(ctx, c)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		states []check.Status
+		items  []Item
+		counts = map[string]int{"green": 0, "yellow": 0, "red": 0, "unknown": 0}
+	)
+
+	// Requested indicators that are not reported are considered unknown
+	for _, name := range hc.Indicators {
+		if _, ok := report.Indicators[name]; !ok {
+			states = append(states, check.Unknown)
+			counts["unknown"]++
+
+			items = append(items, Item{Status: check.Unknown, Output: name + " is not reported by the cluster"})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(report.Indicators)) {
+		if len(hc.Indicators) > 0 && !slices.Contains(hc.Indicators, name) {
+			continue
+		}
+
+		if slices.Contains(hc.ExcludeIndicators, name) {
+			continue
+		}
+
+		indicator := report.Indicators[name]
+		rc := colorToStatus(indicator.Status)
+
+		states = append(states, rc)
+
+		if _, ok := counts[indicator.Status]; ok {
+			counts[indicator.Status]++
+		} else {
+			counts["unknown"]++
+		}
+
+		item := Item{Status: rc, Output: fmt.Sprintf(healthReportOutput, name, indicator.Status, indicator.Symptom)}
+
+		for _, impact := range indicator.Impacts {
+			item.Output += "\n    Impact: " + impact.Description
+		}
+
+		items = append(items, item)
+	}
+
+	var output string
+
+	rc := check.WorstState(states...)
+
+	switch rc {
+	case check.OK:
+		output = fmt.Sprintf("Cluster %s health indicators are green", report.ClusterName)
+	case check.Warning:
+		output = fmt.Sprintf("Cluster %s has yellow health indicators", report.ClusterName)
+	case check.Critical:
+		output = fmt.Sprintf("Cluster %s has red health indicators", report.ClusterName)
+	default:
+		output = fmt.Sprintf("Cluster %s has health indicators in unknown state", report.ClusterName)
+	}
+
+	return &Result{
+		Status:  rc,
+		Summary: output,
+		Items:   items,
+		Perfdata: check.PerfdataList{
+			{Label: "indicators.green", Value: counts["green"]},
+			{Label: "indicators.yellow", Value: counts["yellow"]},
+			{Label: "indicators.red", Value: counts["red"]},
+			{Label: "indicators.unknown", Value: counts["unknown"]},
+		},
+	}, nil
+}
+
+// healthReportFallback evaluates the cluster health for clusters
+// that do not provide the health report API
+func healthReportFallback(ctx context.Context, c *client.Client) (*Result, error) {
+	result, err := (&HealthConfig{}).run(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Summary += " (health report not available, using cluster health)"
+
+	return result, nil
+}
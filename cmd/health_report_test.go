@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+)
+
+type HealthReportTest struct {
+	name     string
+	server   *httptest.Server
+	args     []string
+	expected string
+}
+
+func TestHealthReportCmd(t *testing.T) {
+	tests := []HealthReportTest{
+		{
+			name: "health-report-ok",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"green","cluster_name":"test","indicators":{"master_is_stable":{"status":"green","symptom":"The cluster has a stable master node"},"disk":{"status":"green","symptom":"The cluster has enough available disk space."}}}`))
+			})),
+			args:     []string{"run", "../main.go", "health-report"},
+			expected: "[OK] - Cluster test health indicators are green \n \\_[OK] disk is green: The cluster has enough available disk space.\n \\_[OK] master_is_stable is green: The cluster has a stable master node|indicators.green=2 indicators.yellow=0 indicators.red=0 indicators.unknown=0\n",
+		},
+		{
+			name: "health-report-warning-with-impacts",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"yellow","cluster_name":"test","indicators":{"master_is_stable":{"status":"green","symptom":"The cluster has a stable master node"},"shards_availability":{"status":"yellow","symptom":"This cluster has 1 unavailable replica shard.","impacts":[{"id":"elasticsearch:health:shards_availability:impact:replica_unassigned","severity":2,"description":"Searches might be slower than usual.","impact_areas":["search"]}]}}}`))
+			})),
+			args:     []string{"run", "../main.go", "health-report"},
+			expected: "[WARNING] - Cluster test has yellow health indicators \n \\_[OK] master_is_stable is green: The cluster has a stable master node\n \\_[WARNING] shards_availability is yellow: This cluster has 1 unavailable replica shard.\n    Impact: Searches might be slower than usual.|indicators.green=1 indicators.yellow=1 indicators.red=0 indicators.unknown=0\nexit status 1\n",
+		},
+		{
+			name: "health-report-selected-indicator",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"red","cluster_name":"test","indicators":{"master_is_stable":{"status":"green","symptom":"The cluster has a stable master node"},"disk":{"status":"red","symptom":"1 data node is out of disk."}}}`))
+			})),
+			args:     []string{"run", "../main.go", "health-report", "--indicator", "master_is_stable", "--indicator", "ilm"},
+			expected: "[UNKNOWN] - Cluster test has health indicators in unknown state \n \\_[UNKNOWN] ilm is not reported by the cluster\n \\_[OK] master_is_stable is green: The cluster has a stable master node|indicators.green=1 indicators.yellow=0 indicators.red=0 indicators.unknown=1\nexit status 3\n",
+		},
+		{
+			name: "health-report-excluded-indicator",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"red","cluster_name":"test","indicators":{"master_is_stable":{"status":"green","symptom":"The cluster has a stable master node"},"disk":{"status":"red","symptom":"1 data node is out of disk."}}}`))
+			})),
+			args:     []string{"run", "../main.go", "health-report", "--exclude-indicator", "master_is_stable"},
+			expected: "[CRITICAL] - Cluster test has red health indicators \n \\_[CRITICAL] disk is red: 1 data node is out of disk.|indicators.green=0 indicators.yellow=0 indicators.red=1 indicators.unknown=0\nexit status 2\n",
+		},
+		{
+			name: "health-report-fallback",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				if r.URL.Path == "/_health_report" {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"no handler found for uri [/_health_report] and method [GET]"}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"cluster_name":"test","status":"yellow","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3}`))
+			})),
+			args:     []string{"run", "../main.go", "health-report"},
+			expected: "[WARNING] - Cluster test is yellow (health report not available, using cluster health)|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\nexit status 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			cmd := exec.Command("go", append(test.args, "--hostname", test.server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if actual != test.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+
+		})
+	}
+}
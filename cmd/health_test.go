@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHealth_ConnectionRefused(t *testing.T) {
@@ -14,13 +15,242 @@ func TestHealth_ConnectionRefused(t *testing.T) {
 	out, _ := cmd.CombinedOutput()
 
 	actual := string(out)
-	expected := "[UNKNOWN] - could not fetch cluster health: no node reachable (*errors.errorString)"
+	expected := "[UNKNOWN] - could not fetch cluster health: no node answered successfully: http://localhost:9999: "
 
 	if !strings.Contains(actual, expected) {
 		t.Error("\nActual: ", actual, "\nExpected: ", expected)
 	}
 }
 
+func TestHealth_ConnectionRefusedJSON(t *testing.T) {
+
+	cmd := exec.Command("go", "run", "../main.go", "health", "--output", "json", "--hostname", "http://localhost:9999")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := `{"state":"UNKNOWN","exit_code":3,"summary":"could not fetch cluster health: no node answered successfully: http://localhost:9999: `
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestHealth_InvalidConfigJSON(t *testing.T) {
+	testcases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"ca-file": {
+			args:     []string{"--ca-file", "/nonexistent/ca.pem"},
+			expected: `{"state":"UNKNOWN","exit_code":3,"summary":"unable to load CA cert /nonexistent/ca.pem: `,
+		},
+		"hostname": {
+			args:     []string{"--hostname", "http://local host"},
+			expected: `{"state":"UNKNOWN","exit_code":3,"summary":"invalid value for --hostname: `,
+		},
+		"unknown-flag": {
+			args:     []string{"--unknown"},
+			expected: `{"state":"UNKNOWN","exit_code":3,"summary":"unknown flag: --unknown"`,
+		},
+		"profile": {
+			args:     []string{"--profile", "prod"},
+			expected: `{"state":"UNKNOWN","exit_code":3,"summary":"--profile requires a configuration file (--config)"`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"run", "../main.go", "health", "--output", "json"}, tc.args...)
+
+			cmd := exec.Command("go", args...)
+			out, _ := cmd.Output()
+
+			actual := string(out)
+
+			if !strings.HasPrefix(actual, tc.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
+
+func TestHealth_Failover(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_name":"test","status":"green","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":0,"delayed_unassigned_shards":0,"number_of_pending_tasks":0,"number_of_in_flight_fetch":0,"task_max_waiting_in_queue_millis":0,"active_shards_percent_as_number":100.0}`))
+	}))
+	defer healthy.Close()
+
+	hosts := []string{"--hostname", unavailable.URL, "--hostname", healthy.URL}
+
+	cmd := exec.Command("go", append([]string{"run", "../main.go", "health"}, hosts...)...)
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[OK] - Cluster test is green|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	cmd = exec.Command("go", append([]string{"run", "../main.go", "health", "--failover-warning"}, hosts...)...)
+	out, _ = cmd.CombinedOutput()
+
+	actual = string(out)
+	expected = "[WARNING] - Cluster test is green (failover, failed nodes: " + unavailable.URL + ": 503 Service Unavailable)|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\nexit status 1\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	// An empty --failover-status disables the failover on status codes
+	cmd = exec.Command("go", append([]string{"run", "../main.go", "health", "--failover-status", ""}, hosts...)...)
+	out, _ = cmd.CombinedOutput()
+
+	actual = string(out)
+	expected = "[UNKNOWN] - could not fetch cluster health: could not fetch root endpoint: 503 Service Unavailable (*errors.errorString)\nexit status 3\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestHealth_Retries(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if requests < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_name":"test","status":"green","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":0,"delayed_unassigned_shards":0,"number_of_pending_tasks":0,"number_of_in_flight_fetch":0,"task_max_waiting_in_queue_millis":0,"active_shards_percent_as_number":100.0}`))
+	}))
+	defer server.Close()
+
+	cmd := exec.Command("go", "run", "../main.go", "health", "--hostname", server.URL, "--retries", "1", "--retry-backoff", "10ms")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - could not fetch cluster health: no node answered successfully: " + server.URL + ": 429 Too Many Requests"
+
+	if !strings.Contains(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	cmd = exec.Command("go", "run", "../main.go", "health", "--hostname", server.URL, "--retries", "2", "--retry-backoff", "10ms")
+	out, _ = cmd.CombinedOutput()
+
+	actual = string(out)
+	expected = "[OK] - Cluster test is green|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestHealth_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slower than the request deadline of the plugin
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	cmd := exec.Command("go", "run", "../main.go", "health", "--hostname", server.URL, "--timeout", "2")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - could not fetch cluster health: timeout exceeded: no node answered successfully: " + server.URL + ": context deadline exceeded"
+
+	if !strings.Contains(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestHealth_MultiCluster(t *testing.T) {
+	newCluster := func(name, status string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Elastic-Product", "Elasticsearch")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"cluster_name":"` + name + `","status":"` + status + `","number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3}`))
+		}))
+	}
+
+	eu := newCluster("eu", "green")
+	defer eu.Close()
+
+	ap := newCluster("ap", "yellow")
+	defer ap.Close()
+
+	us := newCluster("us", "red")
+	defer us.Close()
+
+	hosts := []string{"--hostname", eu.URL, "--hostname", ap.URL, "--hostname", us.URL, "--hostname", "http://localhost:9999"}
+	summary := "\n \\_[OK] Cluster eu is green (" + eu.URL + ")" +
+		"\n \\_[WARNING] Cluster ap is yellow (" + ap.URL + ")" +
+		"\n \\_[CRITICAL] Cluster us is red (" + us.URL + ")" +
+		"\n \\_[UNKNOWN] http://localhost:9999: could not fetch cluster health: no node answered successfully: http://localhost:9999: "
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "worst",
+			args:     []string{"--multi-cluster"},
+			expected: "[CRITICAL] - 1 of 4 clusters are green " + summary,
+		},
+		{
+			name:     "best",
+			args:     []string{"--multi-cluster", "--aggregation", "best"},
+			expected: "[OK] - 1 of 4 clusters are green " + summary,
+		},
+		{
+			name:     "min-healthy-ok",
+			args:     []string{"--multi-cluster", "--min-healthy", "2"},
+			expected: "[OK] - 2 of 4 clusters are healthy " + summary,
+		},
+		{
+			name:     "min-healthy-critical",
+			args:     []string{"--multi-cluster", "--min-healthy", "3"},
+			expected: "[CRITICAL] - 2 of 4 clusters are healthy " + summary,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"run", "../main.go", "health"}, test.args...)
+
+			cmd := exec.Command("go", append(args, hosts...)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.HasPrefix(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+
+			if !strings.Contains(actual, "|clusters.green=1 clusters.yellow=1 clusters.red=1 clusters.unknown=1") {
+				t.Error("\nActual: ", actual, "\nExpected perfdata for 4 clusters")
+			}
+		})
+	}
+}
+
 type HealthTest struct {
 	name     string
 	server   *httptest.Server
@@ -28,6 +258,41 @@ type HealthTest struct {
 	expected string
 }
 
+func TestHealth_MultiClusterRunner(t *testing.T) {
+	green := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Write([]byte(`{"cluster_name":"eu","status":"green"}`))
+	}))
+	defer green.Close()
+
+	yellow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Write([]byte(`{"cluster_name":"us","status":"yellow"}`))
+	}))
+	defer yellow.Close()
+
+	// The clusters are the nodes of the client, e.g. of a profile, not the --hostname
+	cfg := Config{Hostnames: []string{green.URL, yellow.URL}}
+
+	c, err := cfg.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hc := HealthConfig{MultiCluster: true, Aggregation: "worst"}
+
+	result, err := hc.run(t.Context(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1 of 2 clusters are green \n \\_[OK] Cluster eu is green (" + green.URL + ")\n \\_[WARNING] Cluster us is yellow (" + yellow.URL + ")"
+
+	if result.Output() != expected {
+		t.Error("\nActual: ", result.Output(), "\nExpected: ", expected)
+	}
+}
+
 func TestHealthCmd(t *testing.T) {
 	tests := []HealthTest{
 		{
@@ -66,6 +331,39 @@ func TestHealthCmd(t *testing.T) {
 			args:     []string{"run", "../main.go", "--bearer", "secret", "health"},
 			expected: "[OK] - Cluster test is green|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\n",
 		},
+		{
+			name: "health-api-key-ok",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token := r.Header.Get("Authorization")
+				// base64 of "id:key"
+				if token == "ApiKey aWQ6a2V5" {
+					w.Header().Set("X-Elastic-Product", "Elasticsearch")
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"cluster_name":"test","status":"green","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":0,"delayed_unassigned_shards":0,"number_of_pending_tasks":0,"number_of_in_flight_fetch":0,"task_max_waiting_in_queue_millis":0,"active_shards_percent_as_number":100.0}`))
+					return
+				}
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`The Authorization header wasn't set`))
+			})),
+			args:     []string{"run", "../main.go", "--api-key", "id:key", "health"},
+			expected: "[OK] - Cluster test is green|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\n",
+		},
+		{
+			name: "health-api-key-encoded-ok",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token := r.Header.Get("Authorization")
+				if token == "ApiKey aWQ6a2V5" {
+					w.Header().Set("X-Elastic-Product", "Elasticsearch")
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"cluster_name":"test","status":"green","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":0,"delayed_unassigned_shards":0,"number_of_pending_tasks":0,"number_of_in_flight_fetch":0,"task_max_waiting_in_queue_millis":0,"active_shards_percent_as_number":100.0}`))
+					return
+				}
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`The Authorization header wasn't set`))
+			})),
+			args:     []string{"run", "../main.go", "--api-key", "aWQ6a2V5", "health"},
+			expected: "[OK] - Cluster test is green|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\n",
+		},
 		{
 			name: "health-invalid",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -76,6 +374,41 @@ func TestHealthCmd(t *testing.T) {
 			args:     []string{"run", "../main.go", "health"},
 			expected: "[UNKNOWN] - Cluster status unknown|nodes=0 data_nodes=0 active_primary_shards=0 active_shards=0\nexit status 3\n",
 		},
+		{
+			name: "health-opensearch",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				if r.URL.Path == "/" {
+					w.Write([]byte(`{"name":"node-1","cluster_name":"test","version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+					return
+				}
+				w.Write([]byte(`{"cluster_name":"test","status":"yellow","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"discovered_master":true,"discovered_cluster_manager":true,"active_primary_shards":3,"active_shards":3,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":1,"delayed_unassigned_shards":0,"number_of_pending_tasks":0,"number_of_in_flight_fetch":0,"task_max_waiting_in_queue_millis":0,"active_shards_percent_as_number":75.0}`))
+			})),
+			args:     []string{"run", "../main.go", "health"},
+			expected: "[WARNING] - Cluster test is yellow|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\nexit status 1\n",
+		},
+		{
+			name: "health-opensearch-no-cluster-manager",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				if r.URL.Path == "/" {
+					w.Write([]byte(`{"name":"node-1","cluster_name":"test","version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+					return
+				}
+				w.Write([]byte(`{"cluster_name":"test","status":"yellow","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"discovered_master":false,"discovered_cluster_manager":false,"active_primary_shards":3,"active_shards":3}`))
+			})),
+			args:     []string{"run", "../main.go", "health"},
+			expected: "[CRITICAL] - Cluster test is yellow, no cluster manager discovered|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\nexit status 2\n",
+		},
+		{
+			name: "health-not-elasticsearch",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`<html><body>Please log in</body></html>`))
+			})),
+			args:     []string{"run", "../main.go", "health"},
+			expected: "[UNKNOWN] - could not fetch cluster health: the server is not Elasticsearch: unexpected answer of the root endpoint (*errors.errorString)\nexit status 3\n",
+		},
 		{
 			name: "health-404",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
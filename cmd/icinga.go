@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/icinga"
+	"github.com/NETWAYS/go-check"
+	checkhttpconfig "github.com/NETWAYS/go-check-network/http/config"
+	"github.com/spf13/pflag"
+)
+
+// IcingaConfig stores the parameters for the submission of check results to the Icinga 2 API
+type IcingaConfig struct {
+	Submit   bool
+	URL      string
+	Host     string
+	Service  string
+	Username string `env:"CHECK_ELASTICSEARCH_ICINGA_USERNAME"`
+	Password string `env:"CHECK_ELASTICSEARCH_ICINGA_PASSWORD"`
+	CAFile   string `env:"CHECK_ELASTICSEARCH_ICINGA_CA_FILE"`
+	CertFile string `env:"CHECK_ELASTICSEARCH_ICINGA_CERT_FILE"`
+	KeyFile  string `env:"CHECK_ELASTICSEARCH_ICINGA_KEY_FILE"`
+	Insecure bool   `env:"CHECK_ELASTICSEARCH_ICINGA_INSECURE"`
+}
+
+var cliIcingaConfig IcingaConfig
+
+func (ic *IcingaConfig) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&ic.Submit, "submit-icinga", false,
+		"Submit the check result to the Icinga 2 API instead of exiting with its state")
+	fs.StringVar(&ic.URL, "icinga-url", "https://localhost:5665",
+		"URL of the Icinga 2 API")
+	fs.StringVar(&ic.Host, "icinga-host", "",
+		"Name of the Icinga 2 host of the check result")
+	fs.StringVar(&ic.Service, "icinga-service", "",
+		"Name of the Icinga 2 service of the check result. If not set the result is submitted for the host")
+	fs.StringVar(&ic.Username, "icinga-username", "",
+		"Username of the Icinga 2 API user (CHECK_ELASTICSEARCH_ICINGA_USERNAME)")
+	fs.StringVar(&ic.Password, "icinga-password", "",
+		"Password of the Icinga 2 API user (CHECK_ELASTICSEARCH_ICINGA_PASSWORD)")
+	fs.StringVar(&ic.CAFile, "icinga-ca-file", "",
+		"Specify the CA File for TLS authentication with the Icinga 2 API (CHECK_ELASTICSEARCH_ICINGA_CA_FILE)")
+	fs.StringVar(&ic.CertFile, "icinga-cert-file", "",
+		"Specify the Certificate File for TLS authentication with the Icinga 2 API (CHECK_ELASTICSEARCH_ICINGA_CERT_FILE)")
+	fs.StringVar(&ic.KeyFile, "icinga-key-file", "",
+		"Specify the Key File for TLS authentication with the Icinga 2 API (CHECK_ELASTICSEARCH_ICINGA_KEY_FILE)")
+	fs.BoolVar(&ic.Insecure, "icinga-insecure", false,
+		"Skip the verification of the Icinga 2 API's TLS certificate (CHECK_ELASTICSEARCH_ICINGA_INSECURE)")
+}
+
+// validate checks the parameters required for the submission
+func (ic *IcingaConfig) validate() error {
+	if !ic.Submit {
+		return nil
+	}
+
+	if ic.Host == "" {
+		return errors.New("--submit-icinga requires the --icinga-host")
+	}
+
+	if outputFormat != "text" {
+		return errors.New("--submit-icinga can not be used with --output " + outputFormat)
+	}
+
+	return nil
+}
+
+func (ic *IcingaConfig) NewClient() (*icinga.Client, error) {
+	u, err := url.Parse(ic.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for --icinga-url: %w", err)
+	}
+
+	tlsConfig, err := checkhttpconfig.NewTLSConfig(&checkhttpconfig.TLSConfig{
+		InsecureSkipVerify: ic.Insecure,
+		CAFile:             ic.CAFile,
+		KeyFile:            ic.KeyFile,
+		CertFile:           ic.CertFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     tlsConfig,
+	}
+
+	if ic.Username != "" {
+		rt = checkhttpconfig.NewBasicAuthRoundTripper(ic.Username, ic.Password, rt)
+	}
+
+	return icinga.NewClient(u, rt), nil
+}
+
+// submitResults submits the results to the Icinga 2 API, service
+// is the Icinga 2 service of each result
+func (ic *IcingaConfig) submitResults(results []*Result, services []string) error {
+	c, err := ic.NewClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := submitContext()
+	defer cancel()
+
+	// The check source is the host that ran the check, not the Icinga 2 endpoint
+	source, _ := os.Hostname()
+
+	for i, r := range results {
+		cr := icinga.NewCheckResult(ic.Host, services[i])
+		cr.ExitStatus = int(r.Status)
+
+		// The state of a host is either UP (0) or DOWN (1)
+		if cr.Type == "Host" && r.Status != check.OK {
+			cr.ExitStatus = 1
+		}
+		cr.PluginOutput = r.pluginOutput()
+		cr.CheckSource = source
+
+		for _, p := range r.Perfdata {
+			if validPerfdata(p) {
+				cr.PerformanceData = append(cr.PerformanceData, p.String())
+			}
+		}
+
+		err = c.ProcessCheckResult(ctx, cr)
+		if err != nil {
+			return err
+		}
+
+		name := ic.Host
+		if services[i] != "" {
+			name += "!" + services[i]
+		}
+
+		_, _ = os.Stdout.WriteString("Submitted check result for " + name + ": " +
+			strings.SplitN(cr.PluginOutput, "\n", 2)[0] + "\n")
+	}
+
+	return nil
+}
+
+// submit submits the result to the Icinga 2 API and exits with OK,
+// or UNKNOWN when the submission failed
+func (ic *IcingaConfig) submit(r *Result) {
+	err := ic.submitResults([]*Result{r}, []string{ic.Service})
+	if err != nil {
+		check.ExitError(err)
+	}
+
+	check.BaseExit(check.OK)
+}
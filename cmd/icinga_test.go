@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/icinga"
+)
+
+func TestSubmitIcinga(t *testing.T) {
+	elasticsearch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_name":"test","status":"yellow","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3}`))
+	}))
+	defer elasticsearch.Close()
+
+	var received icinga.CheckResult
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "root" || pass != "icinga" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&received)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results":[{"code":200,"status":"Successfully processed check result for object 'es!health'."}]}`))
+	}))
+	defer api.Close()
+
+	cmd := exec.Command("go", "run", "../main.go", "health", "--hostname", elasticsearch.URL,
+		"--submit-icinga", "--icinga-url", api.URL, "--icinga-host", "es", "--icinga-service", "health",
+		"--icinga-username", "root", "--icinga-password", "icinga")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "Submitted check result for es!health: [WARNING] - Cluster test is yellow\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	if received.Type != "Service" || received.Service != "es!health" || received.ExitStatus != 1 ||
+		received.PluginOutput != "[WARNING] - Cluster test is yellow" ||
+		strings.Join(received.PerformanceData, " ") != "nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3" {
+		t.Errorf("unexpected check result: %+v", received)
+	}
+
+	// A failed submission is UNKNOWN
+	cmd = exec.Command("go", "run", "../main.go", "health", "--hostname", elasticsearch.URL,
+		"--submit-icinga", "--icinga-url", api.URL, "--icinga-host", "es")
+	out, _ = cmd.CombinedOutput()
+
+	actual = string(out)
+	expected = "[UNKNOWN] - request failed for check result: 401 Unauthorized"
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestSubmitIcinga_HostResult(t *testing.T) {
+	elasticsearch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_name":"test","status":"red","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3}`))
+	}))
+	defer elasticsearch.Close()
+
+	var received icinga.CheckResult
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results":[{"code":200,"status":"Successfully processed check result for object 'es'."}]}`))
+	}))
+	defer api.Close()
+
+	cmd := exec.Command("go", "run", "../main.go", "health", "--hostname", elasticsearch.URL,
+		"--submit-icinga", "--icinga-url", api.URL, "--icinga-host", "es")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "Submitted check result for es: [CRITICAL] - Cluster test is red\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	// A CRITICAL result is submitted as DOWN for the host
+	if received.Type != "Host" || received.Host != "es" || received.ExitStatus != 1 {
+		t.Errorf("unexpected check result: %+v", received)
+	}
+}
+
+func TestSubmitIcinga_WithoutHost(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "health", "--submit-icinga")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - --submit-icinga requires the --icinga-host"
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// IndexConfig stores the CLI parameters.
+type IndexConfig struct {
+	Pattern            string
+	IndexNames         []string
+	DocsWarning        string
+	DocsCritical       string
+	SizeWarning        string
+	SizeCritical       string
+	DocsGrowthWarning  string
+	DocsGrowthCritical string
+	SizeGrowthWarning  string
+	SizeGrowthCritical string
+	StateFile          string
+}
+
+// indexState is the state file with the values of the previous run
+type indexState struct {
+	Time    int64                      `json:"time"`
+	Indices map[string]indexStateEntry `json:"indices"`
+}
+
+type indexStateEntry struct {
+	Docs      uint64 `json:"docs"`
+	StoreSize uint64 `json:"store_size"`
+}
+
+// indexThresholds are the optional thresholds of the index check, nil when not set
+type indexThresholds struct {
+	docsWarn, docsCrit             *check.Threshold
+	sizeWarn, sizeCrit             *check.Threshold
+	docsGrowthWarn, docsGrowthCrit *check.Threshold
+	sizeGrowthWarn, sizeGrowthCrit *check.Threshold
+}
+
+var cliIndexConfig IndexConfig
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Checks the document count and store size of Elasticsearch indices",
+	Long: `Checks the document count and store size of Elasticsearch indices
+
+The thresholds apply to each index. With --state-file the values are stored
+after each run and the growth since the previous run can be checked, e.g. with
+--docs-growth-critical 1: when an index must grow or --size-growth-critical ~:500GB
+when an index must not grow by more than 500GB. Sizes support the units B, KB, MB,
+GB, TB and PB.`,
+	Example: `
+$ check_elasticsearch index --pattern "logs-*" --size-warning 40GB --size-critical 50GB
+[OK] - All 2 indices are OK
+ \_[OK] logs-2024.01.01: 1000 docs, 20.0GB (primary 10.0GB)
+ \_[OK] logs-2024.01.02: 500 docs, 10.0GB (primary 5.0GB)
+
+$ check_elasticsearch index --index "^logs-" --state-file /var/lib/check_elasticsearch/logs.json --docs-growth-critical 1:
+[CRITICAL] - 1 of 2 indices are not OK
+ \_[OK] logs-2024.01.01: 1000 docs, 20.0GB (primary 10.0GB), +200 docs and +1.0GB since the previous run
+ \_[CRITICAL] logs-2024.01.02: 500 docs, 10.0GB (primary 5.0GB), +0 docs and +0B since the previous run
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliIndexConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+
+	fs := indexCmd.Flags()
+	cliIndexConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (ic *IndexConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&ic.Pattern, "pattern", "*",
+		"Comma-separated list of indices to retrieve. Wildcard (*) expressions are supported")
+	fs.StringArrayVar(&ic.IndexNames, "index", []string{},
+		"Name of the index to check. Can be used multiple times and supports regex.")
+	fs.StringVar(&ic.DocsWarning, "docs-warning", "",
+		"Warning threshold for the document count of an index. Use min:max for a range.")
+	fs.StringVar(&ic.DocsCritical, "docs-critical", "",
+		"Critical threshold for the document count of an index. Use min:max for a range.")
+	fs.StringVar(&ic.SizeWarning, "size-warning", "",
+		"Warning threshold for the store size of an index (e.g. 40GB). Use min:max for a range.")
+	fs.StringVar(&ic.SizeCritical, "size-critical", "",
+		"Critical threshold for the store size of an index (e.g. 50GB). Use min:max for a range.")
+	fs.StringVar(&ic.DocsGrowthWarning, "docs-growth-warning", "",
+		"Warning threshold for the document growth of an index since the previous run. Requires --state-file")
+	fs.StringVar(&ic.DocsGrowthCritical, "docs-growth-critical", "",
+		"Critical threshold for the document growth of an index since the previous run. Requires --state-file")
+	fs.StringVar(&ic.SizeGrowthWarning, "size-growth-warning", "",
+		"Warning threshold for the store size growth of an index since the previous run. Requires --state-file")
+	fs.StringVar(&ic.SizeGrowthCritical, "size-growth-critical", "",
+		"Critical threshold for the store size growth of an index since the previous run. Requires --state-file")
+	fs.StringVar(&ic.StateFile, "state-file", "",
+		"File to store the values of the indices for the growth thresholds")
+}
+
+func (ic *IndexConfig) thresholds() (*indexThresholds, error) {
+	var (
+		t   indexThresholds
+		err error
+	)
+
+	parsers := []struct {
+		threshold **check.Threshold
+		value     string
+		size      bool
+	}{
+		{&t.docsWarn, ic.DocsWarning, false},
+		{&t.docsCrit, ic.DocsCritical, false},
+		{&t.sizeWarn, ic.SizeWarning, true},
+		{&t.sizeCrit, ic.SizeCritical, true},
+		{&t.docsGrowthWarn, ic.DocsGrowthWarning, false},
+		{&t.docsGrowthCrit, ic.DocsGrowthCritical, false},
+		{&t.sizeGrowthWarn, ic.SizeGrowthWarning, true},
+		{&t.sizeGrowthCrit, ic.SizeGrowthCritical, true},
+	}
+
+	for _, p := range parsers {
+		*p.threshold, err = parseOptionalThreshold(p.value, p.size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	growth := t.docsGrowthWarn != nil || t.docsGrowthCrit != nil || t.sizeGrowthWarn != nil || t.sizeGrowthCrit != nil
+	if growth && ic.StateFile == "" {
+		return nil, errors.New("growth thresholds require --state-file")
+	}
+
+	return &t, nil
+}
+
+func (ic *IndexConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		items    []Item
+		perfList check.PerfdataList
+	)
+
+	t, err := ic.thresholds()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := &indexState{}
+
+	err = loadState(ic.StateFile, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	indices, err := c.CatIndices(ctx, ic.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	current := indexState{Time: time.Now().Unix(), Indices: map[string]indexStateEntry{}}
+	states := make([]check.Status, 0, len(indices))
+
+	for _, index := range indices {
+		indexMatched, regexErr := matches(index.Index, ic.IndexNames)
+		if regexErr != nil {
+			return &Result{Status: check.Unknown, Summary: "Invalid regular expression provided: " + regexErr.Error()}, nil
+		}
+
+		if !indexMatched && len(ic.IndexNames) >= 1 {
+			continue
+		}
+
+		// Closed indices provide no statistics
+		if index.DocsCount == "" {
+			states = append(states, check.OK)
+			items = append(items, Item{Status: check.OK, Output: index.Index + ": index is " + index.Status})
+
+			continue
+		}
+
+		docs, errDocs := strconv.ParseUint(index.DocsCount, 10, 64)
+		size, errSize := strconv.ParseUint(index.StoreSize, 10, 64)
+		priSize, errPriSize := strconv.ParseUint(index.PriStoreSize, 10, 64)
+
+		if err = errors.Join(errDocs, errSize, errPriSize); err != nil {
+			return nil, fmt.Errorf("invalid statistics for index %s: %w", index.Index, err)
+		}
+
+		current.Indices[index.Index] = indexStateEntry{Docs: docs, StoreSize: size}
+
+		state := thresholdState(float64(docs), t.docsWarn, t.docsCrit)
+		state = max(state, thresholdState(float64(size), t.sizeWarn, t.sizeCrit))
+
+		output := fmt.Sprintf("%s: %d docs, %s (primary %s)", index.Index, docs, formatBytes(float64(size)), formatBytes(float64(priSize)))
+
+		prefix := "indices." + index.Index + "."
+
+		perfList.Add(&check.Perfdata{Label: prefix + "docs", Value: docs, Warn: t.docsWarn, Crit: t.docsCrit})
+		perfList.Add(&check.Perfdata{Label: prefix + "store_size", Value: size, Uom: "B", Warn: t.sizeWarn, Crit: t.sizeCrit})
+		perfList.Add(&check.Perfdata{Label: prefix + "pri_store_size", Value: priSize, Uom: "B"})
+
+		if prev, ok := previous.Indices[index.Index]; ok && previous.Time > 0 {
+			docsGrowth := float64(docs) - float64(prev.Docs)
+			sizeGrowth := float64(size) - float64(prev.StoreSize)
+
+			state = max(state, thresholdState(docsGrowth, t.docsGrowthWarn, t.docsGrowthCrit))
+			state = max(state, thresholdState(sizeGrowth, t.sizeGrowthWarn, t.sizeGrowthCrit))
+
+			output += fmt.Sprintf(", %+.0f docs and %s since the previous run", docsGrowth, formatGrowth(sizeGrowth))
+
+			perfList.Add(&check.Perfdata{Label: prefix + "docs_growth", Value: docsGrowth,
+				Warn: t.docsGrowthWarn, Crit: t.docsGrowthCrit})
+			perfList.Add(&check.Perfdata{Label: prefix + "store_size_growth", Value: sizeGrowth, Uom: "B",
+				Warn: t.sizeGrowthWarn, Crit: t.sizeGrowthCrit})
+		}
+
+		states = append(states, state)
+		items = append(items, Item{Status: state, Output: output})
+	}
+
+	if ic.StateFile != "" {
+		err = saveState(ic.StateFile, &current)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(states) == 0 {
+		return &Result{Status: check.Unknown, Summary: "No indices found matching " + ic.Pattern}, nil
+	}
+
+	return &Result{
+		Status:   check.WorstState(states...),
+		Summary:  statesSummary(states, "indices"),
+		Items:    items,
+		Perfdata: perfList,
+	}, nil
+}
+
+// formatGrowth returns the size with a sign
+func formatGrowth(value float64) string {
+	if value < 0 {
+		return formatBytes(value)
+	}
+
+	return "+" + formatBytes(value)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndex_ConnectionRefused(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "index", "--hostname", "http://localhost:9999")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - could not fetch indices: no node answered successfully: http://localhost:9999: "
+
+	if !strings.Contains(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+const catIndicesResponse = `[{"index":"logs-2024.01.01","health":"green","status":"open","docs.count":"1000","store.size":"21474836480","pri.store.size":"10737418240"},` +
+	`{"index":"logs-2024.01.02","health":"green","status":"open","docs.count":"500","store.size":"10737418240","pri.store.size":"5368709120"},` +
+	`{"index":"archive","health":null,"status":"close","docs.count":null,"store.size":null,"pri.store.size":null}]`
+
+func TestIndexCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "index-ok",
+			args:     []string{"run", "../main.go", "index", "--index", "^logs-"},
+			expected: "[OK] - All 2 indices are OK \n \\_[OK] logs-2024.01.01: 1000 docs, 20.0GB (primary 10.0GB)\n \\_[OK] logs-2024.01.02: 500 docs, 10.0GB (primary 5.0GB)|indices.logs-2024.01.01.docs=1000 indices.logs-2024.01.01.store_size=21474836480B indices.logs-2024.01.01.pri_store_size=10737418240B indices.logs-2024.01.02.docs=500 indices.logs-2024.01.02.store_size=10737418240B indices.logs-2024.01.02.pri_store_size=5368709120B\n",
+		},
+		{
+			name:     "index-closed",
+			args:     []string{"run", "../main.go", "index", "--index", "archive"},
+			expected: "[OK] - All 1 indices are OK \n \\_[OK] archive: index is close|\n",
+		},
+		{
+			name:     "index-size-critical",
+			args:     []string{"run", "../main.go", "index", "--index", "^logs-", "--size-warning", "12GB", "--size-critical", "15GB"},
+			expected: "[CRITICAL] - 1 of 2 indices are not OK \n \\_[CRITICAL] logs-2024.01.01: 1000 docs, 20.0GB (primary 10.0GB)\n \\_[OK] logs-2024.01.02: 500 docs, 10.0GB (primary 5.0GB)|indices.logs-2024.01.01.docs=1000 indices.logs-2024.01.01.store_size=21474836480B;12884901888;16106127360 indices.logs-2024.01.01.pri_store_size=10737418240B indices.logs-2024.01.02.docs=500 indices.logs-2024.01.02.store_size=10737418240B;12884901888;16106127360 indices.logs-2024.01.02.pri_store_size=5368709120B\nexit status 2\n",
+		},
+		{
+			name:     "index-docs-warning",
+			args:     []string{"run", "../main.go", "index", "--index", "2024.01.02", "--docs-warning", "1000:"},
+			expected: "[WARNING] - 1 of 1 indices are not OK \n \\_[WARNING] logs-2024.01.02: 500 docs, 10.0GB (primary 5.0GB)|indices.logs-2024.01.02.docs=500;1000: indices.logs-2024.01.02.store_size=10737418240B indices.logs-2024.01.02.pri_store_size=5368709120B\nexit status 1\n",
+		},
+		{
+			name:     "index-not-found",
+			args:     []string{"run", "../main.go", "index", "--index", "notpresent"},
+			expected: "[UNKNOWN] - No indices found matching *|\nexit status 3\n",
+		},
+		{
+			name:     "index-growth-without-state",
+			args:     []string{"run", "../main.go", "index", "--docs-growth-critical", "1:"},
+			expected: "[UNKNOWN] - growth thresholds require --state-file (*errors.errorString)\nexit status 3\n",
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(catIndicesResponse))
+	}))
+	defer server.Close()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := exec.Command("go", append(test.args, "--hostname", server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if actual != test.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+		})
+	}
+}
+
+func TestIndex_Growth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(catIndicesResponse))
+	}))
+	defer server.Close()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	err := os.WriteFile(stateFile, []byte(`{"time":1700000000,"indices":{"logs-2024.01.01":{"docs":800,"store_size":20401094656},"logs-2024.01.02":{"docs":500,"store_size":10737418240}}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", "../main.go", "index", "--hostname", server.URL, "--index", "^logs-",
+		"--state-file", stateFile, "--docs-growth-critical", "1:", "--size-growth-warning", "~:500MB")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[CRITICAL] - 2 of 2 indices are not OK \n" +
+		" \\_[WARNING] logs-2024.01.01: 1000 docs, 20.0GB (primary 10.0GB), +200 docs and +1.0GB since the previous run\n" +
+		" \\_[CRITICAL] logs-2024.01.02: 500 docs, 10.0GB (primary 5.0GB), +0 docs and +0B since the previous run|"
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	if !strings.Contains(actual, "indices.logs-2024.01.01.docs_growth=200;;1: indices.logs-2024.01.01.store_size_growth=1073741824B;~:524288000 ") {
+		t.Error("\nActual: ", actual, "\nExpected growth perfdata")
+	}
+
+	state, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(state), `"logs-2024.01.01":{"docs":1000,"store_size":21474836480}`) {
+		t.Error("state file not updated: ", string(state))
+	}
+}
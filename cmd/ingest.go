@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"regexp"
-	"strings"
 
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
 	"github.com/NETWAYS/go-check"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // PipelineConfig stores the CLI parameters.
@@ -16,7 +18,7 @@ type PipelineConfig struct {
 	FailedCritical string
 }
 
-const ingestOutput = "%s Number of failed ingest operations for %s: %g"
+const ingestOutput = "Number of failed ingest operations for %s: %g"
 
 var cliPipelineConfig PipelineConfig
 
@@ -25,118 +27,119 @@ var ingestCmd = &cobra.Command{
 	Short: "Checks the ingest statistics of Ingest Pipelines",
 	Long:  `Checks the ingest statistics of Ingest Pipelines`,
 	Run: func(_ *cobra.Command, _ []string) {
-		var (
-			rc       check.Status
-			output   string
-			perfList check.PerfdataList
-		)
+		runCheck(&cliPipelineConfig)
+	},
+}
 
-		failedCrit, err := check.ParseThreshold(cliPipelineConfig.FailedCritical)
-		if err != nil {
-			check.ExitError(err)
-		}
+func init() {
+	rootCmd.AddCommand(ingestCmd)
 
-		failedWarn, err := check.ParseThreshold(cliPipelineConfig.FailedWarning)
-		if err != nil {
-			check.ExitError(err)
-		}
+	fs := ingestCmd.Flags()
+	cliPipelineConfig.addFlags(fs)
+	fs.SortFlags = false
+}
 
-		client := cliConfig.NewClient()
+func (pc *PipelineConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&pc.PipelineNames, "pipeline", []string{},
+		"Name of the pipeline to check. Can be used multiple times and supports regex.")
+	fs.StringVar(&pc.FailedWarning, "failed-warning", "10",
+		"Warning threshold for failed ingest operations. Use min:max for a range.")
+	fs.StringVar(&pc.FailedCritical, "failed-critical", "20",
+		"Critical threshold for failed ingest operations. Use min:max for a range.")
+}
 
-		stats, err := client.NodeStats()
-		if err != nil {
-			check.ExitError(err)
-		}
+func (pc *PipelineConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		rc       check.Status
+		output   string
+		items    []Item
+		perfList check.PerfdataList
+	)
+
+	failedCrit, err := check.ParseThreshold(pc.FailedCritical)
+	if err != nil {
+		return nil, err
+	}
 
-		// Calculate states capacity
-		amountOfNodes := 0
-		for _, node := range stats.Nodes {
-			amountOfNodes += len(node.Ingest.Pipelines)
-		}
+	failedWarn, err := check.ParseThreshold(pc.FailedWarning)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := c.NodeStats(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		states := make([]check.Status, 0, amountOfNodes)
-
-		// Check status for each pipeline
-		var summary strings.Builder
-
-		for _, node := range stats.Nodes {
-			for pipelineName, pp := range node.Ingest.Pipelines {
-				pipelineMatched, regexErr := matches(pipelineName, cliPipelineConfig.PipelineNames)
-				if regexErr != nil {
-					check.Exit(check.Unknown, "Invalid regular expression provided:", regexErr.Error())
-				}
-
-				if !pipelineMatched && len(cliPipelineConfig.PipelineNames) >= 1 {
-					// If the pipeline doesn't matches a regex from the list we can skip it.
-					continue
-				}
-
-				summary.WriteString("\n \\_")
-
-				if failedCrit.DoesViolate(pp.Failed) {
-					states = append(states, check.Critical)
-
-					fmt.Fprintf(&summary, ingestOutput, "[CRITICAL]", pipelineName, pp.Failed)
-				} else if failedWarn.DoesViolate(pp.Failed) {
-					states = append(states, check.Warning)
-
-					fmt.Fprintf(&summary, ingestOutput, "[WARNING]", pipelineName, pp.Failed)
-				} else {
-					states = append(states, check.OK)
-
-					fmt.Fprintf(&summary, ingestOutput, "[OK]", pipelineName, pp.Failed)
-				}
-
-				perfList.Add(&check.Perfdata{
-					Label: fmt.Sprintf("pipelines.%s.failed", pipelineName),
-					Uom:   "c",
-					Warn:  failedWarn,
-					Crit:  failedCrit,
-					Value: pp.Failed})
-				perfList.Add(&check.Perfdata{
-					Label: fmt.Sprintf("pipelines.%s.count", pipelineName),
-					Uom:   "c",
-					Value: pp.Count})
-				perfList.Add(&check.Perfdata{
-					Label: fmt.Sprintf("pipelines.%s.current", pipelineName),
-					Value: pp.Current})
+	// Calculate states capacity
+	amountOfNodes := 0
+	for _, node := range stats.Nodes {
+		amountOfNodes += len(node.Ingest.Pipelines)
+	}
+
+	states := make([]check.Status, 0, amountOfNodes)
+
+	// Check status for each pipeline
+	for _, node := range stats.Nodes {
+		for pipelineName, pp := range node.Ingest.Pipelines {
+			pipelineMatched, regexErr := matches(pipelineName, pc.PipelineNames)
+			if regexErr != nil {
+				return &Result{Status: check.Unknown, Summary: "Invalid regular expression provided: " + regexErr.Error()}, nil
 			}
-		}
 
-		// Validate the various subchecks and use the worst state as return code
-		//nolint:exhaustive
-		switch check.WorstState(states...) {
-		case 0:
-			rc = check.OK
-			output = "Ingest operations alright"
-		case 1:
-			rc = check.Warning
-			output = "Ingest operations may not be alright"
-		case 2:
-			rc = check.Critical
-			output = "Ingest operations not alright"
-		default:
-			rc = check.Unknown
-			output = "Ingest operations status unknown"
-		}
+			if !pipelineMatched && len(pc.PipelineNames) >= 1 {
+				// If the pipeline doesn't matches a regex from the list we can skip it.
+				continue
+			}
 
-		check.ExitWithPerfdata(rc, perfList, output, summary.String())
-	},
-}
+			if failedCrit.DoesViolate(pp.Failed) {
+				states = append(states, check.Critical)
 
-func init() {
-	rootCmd.AddCommand(ingestCmd)
+				items = append(items, Item{Status: check.Critical, Output: fmt.Sprintf(ingestOutput, pipelineName, pp.Failed)})
+			} else if failedWarn.DoesViolate(pp.Failed) {
+				states = append(states, check.Warning)
 
-	fs := ingestCmd.Flags()
+				items = append(items, Item{Status: check.Warning, Output: fmt.Sprintf(ingestOutput, pipelineName, pp.Failed)})
+			} else {
+				states = append(states, check.OK)
 
-	fs.StringArrayVar(&cliPipelineConfig.PipelineNames, "pipeline", []string{},
-		"Name of the pipeline to check. Can be used multiple times and supports regex.")
-	fs.StringVar(&cliPipelineConfig.FailedWarning, "failed-warning", "10",
-		"Warning threshold for failed ingest operations. Use min:max for a range.")
-	fs.StringVar(&cliPipelineConfig.FailedCritical, "failed-critical", "20",
-		"Critical threshold for failed ingest operations. Use min:max for a range.")
+				items = append(items, Item{Status: check.OK, Output: fmt.Sprintf(ingestOutput, pipelineName, pp.Failed)})
+			}
 
-	fs.SortFlags = false
+			perfList.Add(&check.Perfdata{
+				Label: fmt.Sprintf("pipelines.%s.failed", pipelineName),
+				Uom:   "c",
+				Warn:  failedWarn,
+				Crit:  failedCrit,
+				Value: pp.Failed})
+			perfList.Add(&check.Perfdata{
+				Label: fmt.Sprintf("pipelines.%s.count", pipelineName),
+				Uom:   "c",
+				Value: pp.Count})
+			perfList.Add(&check.Perfdata{
+				Label: fmt.Sprintf("pipelines.%s.current", pipelineName),
+				Value: pp.Current})
+		}
+	}
+
+	// Validate the various subchecks and use the worst state as return code
+	//nolint:exhaustive
+	switch check.WorstState(states...) {
+	case 0:
+		rc = check.OK
+		output = "Ingest operations alright"
+	case 1:
+		rc = check.Warning
+		output = "Ingest operations may not be alright"
+	case 2:
+		rc = check.Critical
+		output = "Ingest operations not alright"
+	default:
+		rc = check.Unknown
+		output = "Ingest operations status unknown"
+	}
+
+	return &Result{Status: rc, Summary: output, Items: items, Perfdata: perfList}, nil
 }
 
 // Matches a list of regular expressions against a string.
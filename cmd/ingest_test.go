@@ -14,7 +14,7 @@ func TestIngest_ConnectionRefused(t *testing.T) {
 	out, _ := cmd.CombinedOutput()
 
 	actual := string(out)
-	expected := "[UNKNOWN] - could not fetch cluster nodes statistics: no node reachable (*errors.errorString)"
+	expected := "[UNKNOWN] - could not fetch cluster nodes statistics: no node answered successfully: http://localhost:9999: "
 
 	if !strings.Contains(actual, expected) {
 		t.Error("\nActual: ", actual, "\nExpected: ", expected)
@@ -33,6 +33,7 @@ func TestIngestCmd(t *testing.T) {
 		{
 			name: "ingest-ok",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`{"_nodes":{"total":1,"successful":1,"failed":0},"cluster_name":"clustername","nodes":{"node1":{"ip":"127.0.0.1:9300","ingest":{"total":{"count":10,"time_in_millis":0,"current":3,"failed":5},"pipelines":{"mypipeline":{"count":10,"time_in_millis":0,"current":3,"failed":5,"processors":[{"set":{"type":"set","stats":{"count":0,"time_in_millis":0,"current":0,"failed":0}}}]}}}}}}`))
 			})),
@@ -42,6 +43,7 @@ func TestIngestCmd(t *testing.T) {
 		{
 			name: "ingest-ok-with-name",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`{"_nodes":{"total":1,"successful":1,"failed":0},"cluster_name":"clustername","nodes":{"mm3U-u0WTCeuZ325vZGY2w":{"ip":"127.0.0.1:9300","ingest":{"total":{"count":10,"time_in_millis":0,"current":3,"failed":5},"pipelines":{"foobar":{"count":10,"time_in_millis":0,"current":3,"failed":5,"processors":[{"set":{"type":"set","stats":{"count":0,"time_in_millis":0,"current":0,"failed":0}}}]},"mypipeline":{"count":10,"time_in_millis":0,"current":3,"failed":5,"processors":[{"set":{"type":"set","stats":{"count":0,"time_in_millis":0,"current":0,"failed":0}}}]}}}}}}`))
 			})),
@@ -51,6 +53,7 @@ func TestIngestCmd(t *testing.T) {
 		{
 			name: "ingest-warn",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`{"_nodes":{"total":1,"successful":1,"failed":0},"cluster_name":"clustername","nodes":{"node1":{"ip":"127.0.0.1:9300","ingest":{"total":{"count":10,"time_in_millis":0,"current":3,"failed":5},"pipelines":{"mypipeline":{"count":10,"time_in_millis":0,"current":3,"failed":5,"processors":[{"set":{"type":"set","stats":{"count":0,"time_in_millis":0,"current":0,"failed":0}}}]}}}}}}`))
 			})),
@@ -60,6 +63,7 @@ func TestIngestCmd(t *testing.T) {
 		{
 			name: "ingest-crit",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`{"_nodes":{"total":1,"successful":1,"failed":0},"cluster_name":"clustername","nodes":{"node1":{"ip":"127.0.0.1:9300","ingest":{"total":{"count":10,"time_in_millis":0,"current":3,"failed":5},"pipelines":{"mypipeline":{"count":10,"time_in_millis":0,"current":3,"failed":5,"processors":[{"set":{"type":"set","stats":{"count":0,"time_in_millis":0,"current":0,"failed":0}}}]}}}}}}`))
 			})),
@@ -69,11 +73,22 @@ func TestIngestCmd(t *testing.T) {
 		{
 			name: "ingest-invalid",
 			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`{}`))
 			})),
 			args:     []string{"run", "../main.go", "ingest"},
-			expected: "[UNKNOWN] - Ingest operations status unknown |\nexit status 3\n",
+			expected: "[UNKNOWN] - Ingest operations status unknown|\nexit status 3\n",
+		},
+		{
+			name: "ingest-json",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"_nodes":{"total":1,"successful":1,"failed":0},"cluster_name":"clustername","nodes":{"node1":{"ip":"127.0.0.1:9300","ingest":{"total":{"count":10,"time_in_millis":0,"current":3,"failed":5},"pipelines":{"mypipeline":{"count":10,"time_in_millis":0,"current":3,"failed":5,"processors":[{"set":{"type":"set","stats":{"count":0,"time_in_millis":0,"current":0,"failed":0}}}]}}}}}}`))
+			})),
+			args:     []string{"run", "../main.go", "ingest", "--failed-warning", "3", "--output", "json"},
+			expected: `{"state":"WARNING","exit_code":1,"summary":"Ingest operations may not be alright","items":[{"state":"WARNING","output":"Number of failed ingest operations for mypipeline: 5"}],"metrics":[{"label":"pipelines.mypipeline.failed","value":5,"unit":"c","warning":"3","critical":"20"},{"label":"pipelines.mypipeline.count","value":10,"unit":"c"},{"label":"pipelines.mypipeline.current","value":3}]}` + "\nexit status 1\n",
 		},
 	}
 
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// LicenseConfig stores the CLI parameters.
+type LicenseConfig struct {
+	WarningDays  int
+	CriticalDays int
+}
+
+var cliLicenseConfig LicenseConfig
+
+var licenseCmd = &cobra.Command{
+	Use:   "license",
+	Short: "Checks the license status and expiry of an Elasticsearch cluster",
+	Long: `Checks the license status and expiry of an Elasticsearch cluster
+
+The plugin alerts when:
+	the license status is not active = CRITICAL
+	the license expires within the given number of days = WARNING/CRITICAL
+
+Licenses without an expiry date (e.g. basic) only evaluate the status.`,
+	Example: `
+$ check_elasticsearch license
+[OK] - License platinum is active, expires in 120 days (2026-02-16)
+
+$ check_elasticsearch license --warning 60 --critical 14
+[WARNING] - License platinum is active, expires in 42 days (2025-11-30)
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliLicenseConfig)
+	},
+}
+
+// expiryThreshold returns a threshold that is violated when
+// less than the given number of days remain
+func expiryThreshold(days int) *check.Threshold {
+	return &check.Threshold{Lower: float64(days), Upper: check.PosInf}
+}
+
+func init() {
+	rootCmd.AddCommand(licenseCmd)
+
+	fs := licenseCmd.Flags()
+	cliLicenseConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (lc *LicenseConfig) addFlags(fs *pflag.FlagSet) {
+	fs.IntVarP(&lc.WarningDays, "warning", "w", 30,
+		"Warning threshold for the remaining days until the license expires")
+	fs.IntVarP(&lc.CriticalDays, "critical", "c", 7,
+		"Critical threshold for the remaining days until the license expires")
+}
+
+func (lc *LicenseConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	license, err := c.License(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		states []check.Status
+		output string
+		p      check.PerfdataList
+	)
+
+	if license.License.Status == "active" {
+		states = append(states, check.OK)
+	} else {
+		states = append(states, check.Critical)
+	}
+
+	output = fmt.Sprintf("License %s is %s", license.License.Type, license.License.Status)
+
+	if license.License.ExpiryDateInMillis == 0 {
+		output += " and does not expire"
+	} else {
+		expiry := time.UnixMilli(license.License.ExpiryDateInMillis)
+		remainingDays := math.Floor(time.Until(expiry).Hours() / 24)
+
+		warn := expiryThreshold(lc.WarningDays)
+		crit := expiryThreshold(lc.CriticalDays)
+
+		if crit.DoesViolate(remainingDays) {
+			states = append(states, check.Critical)
+		} else if warn.DoesViolate(remainingDays) {
+			states = append(states, check.Warning)
+		}
+
+		if remainingDays < 0 {
+			output += fmt.Sprintf(", expired %g days ago (%s)", -remainingDays, expiry.Format(time.DateOnly))
+		} else {
+			output += fmt.Sprintf(", expires in %g days (%s)", remainingDays, expiry.Format(time.DateOnly))
+		}
+
+		p.Add(&check.Perfdata{
+			Label: "remaining_days",
+			Value: remainingDays,
+			Warn:  warn,
+			Crit:  crit})
+	}
+
+	return &Result{Status: check.WorstState(states...), Summary: output, Perfdata: p}, nil
+}
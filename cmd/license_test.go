@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type LicenseTest struct {
+	name     string
+	server   *httptest.Server
+	args     []string
+	expected string
+}
+
+func TestLicenseCmd(t *testing.T) {
+	tests := []LicenseTest{
+		{
+			name: "license-basic",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"license":{"status":"active","uid":"1a2b3c","type":"basic","issue_date":"2023-06-10T12:44:53.756Z","issue_date_in_millis":1686401093756,"max_nodes":1000,"issued_to":"test","issuer":"elasticsearch","start_date_in_millis":-1}}`))
+			})),
+			args:     []string{"run", "../main.go", "license"},
+			expected: "[OK] - License basic is active and does not expire|\n",
+		},
+		{
+			name: "license-platinum-ok",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"license":{"status":"active","uid":"1a2b3c","type":"platinum","expiry_date":"2100-01-01T00:00:00.000Z","expiry_date_in_millis":4102444800000,"issued_to":"test","issuer":"elasticsearch"}}`))
+			})),
+			args:     []string{"run", "../main.go", "license"},
+			expected: "[OK] - License platinum is active, expires in ",
+		},
+		{
+			name: "license-platinum-warning",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"license":{"status":"active","uid":"1a2b3c","type":"platinum","expiry_date":"2100-01-01T00:00:00.000Z","expiry_date_in_millis":4102444800000,"issued_to":"test","issuer":"elasticsearch"}}`))
+			})),
+			args:     []string{"run", "../main.go", "license", "--warning", "1000000"},
+			expected: "[WARNING] - License platinum is active, expires in ",
+		},
+		{
+			name: "license-expired",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"license":{"status":"expired","uid":"1a2b3c","type":"platinum","expiry_date":"2020-07-06T21:55:18.129Z","expiry_date_in_millis":1593093628850,"issued_to":"test","issuer":"elasticsearch"}}`))
+			})),
+			args:     []string{"run", "../main.go", "license"},
+			expected: "[CRITICAL] - License platinum is expired, expired ",
+		},
+		{
+			name: "license-unauthorized",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{}`))
+			})),
+			args:     []string{"run", "../main.go", "license"},
+			expected: "[UNKNOWN] - request failed for license: 401 Unauthorized (*errors.errorString)",
+		},
+		{
+			name: "license-opensearch",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/" {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"name":"node-1","cluster_name":"test","version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+					return
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"no handler found for uri [/_license] and method [GET]"}`))
+			})),
+			args:     []string{"run", "../main.go", "license"},
+			expected: "[UNKNOWN] - API not supported by OpenSearch (*errors.errorString)",
+		},
+		{
+			name: "license-forbidden-root-cause",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":{"root_cause":[{"type":"security_exception","reason":"action [cluster:monitor/xpack/license/get] is unauthorized for user [monitoring]"}],"type":"security_exception","reason":"action [cluster:monitor/xpack/license/get] is unauthorized for user [monitoring]"},"status":403}`))
+			})),
+			args:     []string{"run", "../main.go", "license"},
+			expected: "[UNKNOWN] - request failed for license: 403 Forbidden: action [cluster:monitor/xpack/license/get] is unauthorized for user [monitoring] (*errors.errorString)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			cmd := exec.Command("go", append(test.args, "--hostname", test.server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.Contains(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	es "github.com/NETWAYS/check_elasticsearch/internal/elasticsearch"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// MappingsConfig stores the CLI parameters.
+type MappingsConfig struct {
+	Pattern       string
+	Warning       string
+	Critical      string
+	ConflictState string
+	Top           int
+}
+
+// defaultTotalFieldsLimit is the default of index.mapping.total_fields.limit
+const defaultTotalFieldsLimit = 1000
+
+// indexFields is the number of mapped fields of an index and its limit
+type indexFields struct {
+	index  string
+	fields int
+	limit  int
+	usage  float64
+}
+
+var cliMappingsConfig MappingsConfig
+
+var mappingsCmd = &cobra.Command{
+	Use:   "mappings",
+	Short: "Checks the number of mapped fields and conflicting field types of Elasticsearch indices",
+	Long: `Checks the number of mapped fields and conflicting field types of Elasticsearch indices
+
+The mapped fields of each index are counted like Elasticsearch does for the
+index.mapping.total_fields.limit setting, including object fields, multi-fields
+and runtime fields. The thresholds are in percent of the limit of the index,
+when the limit is reached documents with new fields are rejected.
+
+Fields with different types in the indices of the pattern are conflicting, e.g.
+a field that is a keyword in one index and a long in another index. Unrelated
+indices often have conflicting fields, so conflicts are only listed unless a
+--conflict-state is given, which is useful with a --pattern of related indices.`,
+	Example: `
+$ check_elasticsearch mappings --pattern "logs-*" --conflict-state WARNING --top 2
+[WARNING] - 1 of 3 indices are not OK, 1 fields with conflicting types
+ \_[WARNING] logs-2024.01.02: 850 of 1000 fields (85%)
+ \_[OK] logs-2024.01.01: 612 of 1000 fields (61%)
+ \_[WARNING] status has conflicting types: keyword (logs-2024.01.01), long (logs-2024.01.02)
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliMappingsConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mappingsCmd)
+
+	fs := mappingsCmd.Flags()
+	cliMappingsConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (mc *MappingsConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&mc.Pattern, "pattern", "*",
+		"Comma-separated list of indices to retrieve. Wildcard (*) expressions are supported")
+	fs.StringVar(&mc.Warning, "warning", "80",
+		"Warning threshold for the mapped fields of an index in percent of index.mapping.total_fields.limit")
+	fs.StringVar(&mc.Critical, "critical", "90",
+		"Critical threshold for the mapped fields of an index in percent of index.mapping.total_fields.limit")
+	fs.StringVar(&mc.ConflictState, "conflict-state", "OK",
+		"State to assign when fields have conflicting types (0, 1, 2, 3, OK, WARNING, CRITICAL, UNKNOWN)")
+	fs.IntVar(&mc.Top, "top", 5,
+		"Number of the indices with the most mapped fields and of the conflicting fields to list")
+}
+
+func (mc *MappingsConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		items    []Item
+		perfList check.PerfdataList
+	)
+
+	conflictState, err := check.NewStatusFromString(mc.ConflictState)
+	if err != nil {
+		return nil, err
+	}
+
+	crit, err := check.ParseThreshold(mc.Critical)
+	if err != nil {
+		return nil, err
+	}
+
+	warn, err := check.ParseThreshold(mc.Warning)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := c.Mappings(ctx, mc.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mappings) == 0 {
+		return &Result{Status: check.Unknown, Summary: "No indices found matching " + mc.Pattern}, nil
+	}
+
+	settings, err := c.IndexSettings(ctx, mc.Pattern, "index.mapping.total_fields.limit")
+	if err != nil {
+		return nil, err
+	}
+
+	caps, err := c.FieldCaps(ctx, mc.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]indexFields, 0, len(mappings))
+
+	for index, mapping := range mappings {
+		limit := defaultTotalFieldsLimit
+
+		if value, ok := settings[index].Setting("index.mapping.total_fields.limit"); ok {
+			limit, err = strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index.mapping.total_fields.limit of index %s: %w", index, err)
+			}
+		}
+
+		fields := countFields(mapping.Mappings)
+		if runtime, ok := mapping.Mappings["runtime"].(map[string]any); ok {
+			fields += len(runtime)
+		}
+
+		indices = append(indices, indexFields{
+			index:  index,
+			fields: fields,
+			limit:  limit,
+			usage:  float64(fields) * 100 / float64(limit),
+		})
+	}
+
+	// The indices closest to their limit first
+	slices.SortFunc(indices, func(a, b indexFields) int {
+		return cmp.Or(cmp.Compare(b.usage, a.usage), cmp.Compare(a.index, b.index))
+	})
+
+	states := make([]check.Status, 0, len(indices))
+
+	for i, idx := range indices {
+		state := check.OK
+
+		if crit.DoesViolate(idx.usage) {
+			state = check.Critical
+		} else if warn.DoesViolate(idx.usage) {
+			state = check.Warning
+		}
+
+		states = append(states, state)
+
+		if i < mc.Top {
+			items = append(items, Item{Status: state, Output: fmt.Sprintf("%s: %d of %d fields (%.0f%%)", idx.index, idx.fields, idx.limit, idx.usage)})
+		}
+
+		prefix := "indices." + idx.index + "."
+
+		perfList.Add(&check.Perfdata{Label: prefix + "fields", Value: idx.fields, Max: idx.limit})
+		perfList.Add(&check.Perfdata{Label: prefix + "fields_usage", Value: idx.usage, Uom: "%", Warn: warn, Crit: crit})
+	}
+
+	conflicts := conflictingFields(caps.Fields)
+
+	for i, field := range conflicts {
+		if i < mc.Top {
+			items = append(items, Item{Status: conflictState, Output: field + " has conflicting types: " + conflictTypes(caps.Fields[field])})
+		}
+	}
+
+	perfList.Add(&check.Perfdata{Label: "conflicting_fields", Value: len(conflicts)})
+
+	summary := statesSummary(states, "indices")
+
+	if len(conflicts) > 0 {
+		states = append(states, conflictState)
+		summary += fmt.Sprintf(", %d fields with conflicting types", len(conflicts))
+	}
+
+	return &Result{Status: check.WorstState(states...), Summary: summary, Items: items, Perfdata: perfList}, nil
+}
+
+// countFields counts the fields of a mapping, including object fields and multi-fields
+func countFields(mapping map[string]any) int {
+	count := 0
+
+	for _, key := range []string{"properties", "fields"} {
+		fields, ok := mapping[key].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			count++
+
+			if m, ok := field.(map[string]any); ok {
+				count += countFields(m)
+			}
+		}
+	}
+
+	return count
+}
+
+// conflictingFields returns the sorted names of the fields with multiple types
+func conflictingFields(fields map[string]map[string]es.FieldCaps) []string {
+	var conflicts []string
+
+	for name, types := range fields {
+		if len(types) > 1 {
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	slices.Sort(conflicts)
+
+	return conflicts
+}
+
+// conflictTypes returns the types of a field with their indices, e.g. keyword (logs-1), long (logs-2)
+func conflictTypes(types map[string]es.FieldCaps) string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+" ("+strings.Join(types[name].Indices, ", ")+")")
+	}
+
+	return strings.Join(parts, ", ")
+}
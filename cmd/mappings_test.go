@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMappings_ConnectionRefused(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "mappings", "--hostname", "http://localhost:9999")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - could not fetch mappings: no node answered successfully: http://localhost:9999: "
+
+	if !strings.Contains(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestMappingsCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+
+		switch r.URL.Path {
+		case "/*/_mapping":
+			w.WriteHeader(http.StatusOK)
+			// logs-1: message, message.keyword, host, host.name, status, runtime day = 6 fields
+			// logs-2: message, status = 2 fields
+			w.Write([]byte(`{"logs-1":{"mappings":{"runtime":{"day":{"type":"keyword"}},"properties":{` +
+				`"message":{"type":"text","fields":{"keyword":{"type":"keyword"}}},` +
+				`"host":{"properties":{"name":{"type":"keyword"}}},"status":{"type":"keyword"}}}},` +
+				`"logs-2":{"mappings":{"properties":{"message":{"type":"text"},"status":{"type":"long"}}}}}`))
+		case "/*/_settings/index.mapping.total_fields.limit":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"logs-1":{"settings":{"index.mapping.total_fields.limit":"8"},"defaults":{}},` +
+				`"logs-2":{"settings":{},"defaults":{"index.mapping.total_fields.limit":"1000"}}}`))
+		case "/*/_field_caps":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"indices":["logs-1","logs-2"],"fields":{` +
+				`"message":{"text":{"type":"text","searchable":true,"aggregatable":false}},` +
+				`"status":{"keyword":{"type":"keyword","searchable":true,"aggregatable":true,"indices":["logs-1"]},` +
+				`"long":{"type":"long","searchable":true,"aggregatable":true,"indices":["logs-2"]}}}}`))
+		case "/none/_mapping":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "mappings-ok",
+			args:     []string{"run", "../main.go", "mappings"},
+			expected: "[OK] - All 2 indices are OK, 1 fields with conflicting types \n \\_[OK] logs-1: 6 of 8 fields (75%)\n \\_[OK] logs-2: 2 of 1000 fields (0%)\n \\_[OK] status has conflicting types: keyword (logs-1), long (logs-2)|indices.logs-1.fields=6;;;;8 indices.logs-1.fields_usage=75%;80;90 indices.logs-2.fields=2;;;;1000 indices.logs-2.fields_usage=0.2%;80;90 conflicting_fields=1\n",
+		},
+		{
+			name:     "mappings-conflict-warning",
+			args:     []string{"run", "../main.go", "mappings", "--conflict-state", "WARNING"},
+			expected: "[WARNING] - All 2 indices are OK, 1 fields with conflicting types \n \\_[OK] logs-1: 6 of 8 fields (75%)\n \\_[OK] logs-2: 2 of 1000 fields (0%)\n \\_[WARNING] status has conflicting types: keyword (logs-1), long (logs-2)|indices.logs-1.fields=6;;;;8 indices.logs-1.fields_usage=75%;80;90 indices.logs-2.fields=2;;;;1000 indices.logs-2.fields_usage=0.2%;80;90 conflicting_fields=1\nexit status 1\n",
+		},
+		{
+			name:     "mappings-critical",
+			args:     []string{"run", "../main.go", "mappings", "--warning", "50", "--critical", "70", "--top", "1"},
+			expected: "[CRITICAL] - 1 of 2 indices are not OK, 1 fields with conflicting types \n \\_[CRITICAL] logs-1: 6 of 8 fields (75%)\n \\_[OK] status has conflicting types: keyword (logs-1), long (logs-2)|indices.logs-1.fields=6;;;;8 indices.logs-1.fields_usage=75%;50;70 indices.logs-2.fields=2;;;;1000 indices.logs-2.fields_usage=0.2%;50;70 conflicting_fields=1\nexit status 2\n",
+		},
+		{
+			name:     "mappings-not-found",
+			args:     []string{"run", "../main.go", "mappings", "--pattern", "none"},
+			expected: "[UNKNOWN] - No indices found matching none|\nexit status 3\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := exec.Command("go", append(test.args, "--hostname", server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if actual != test.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// MultiConfig stores the CLI parameters.
+type MultiConfig struct {
+	File        string
+	Concurrency int
+	PerCheck    bool
+}
+
+// CheckFile represents a file with the definitions of the checks run by the multi command
+//
+//	checks:
+//	  - name: cluster
+//	    check: health
+//	  - name: errors
+//	    check: query
+//	    args: ["--query", "log.level:error", "--warning", "10", "--critical", "50"]
+type CheckFile struct {
+	Checks []CheckDefinition `yaml:"checks"`
+}
+
+// CheckDefinition stores a single check and the flags of its command
+type CheckDefinition struct {
+	Name  string   `yaml:"name"`
+	Check string   `yaml:"check"`
+	Args  []string `yaml:"args"`
+}
+
+// multiCheck is a check definition with its parsed configuration
+type multiCheck struct {
+	name   string
+	check  string
+	runner checkRunner
+}
+
+var cliMultiConfig MultiConfig
+
+var multiCmd = &cobra.Command{
+	Use:   "multi",
+	Short: "Runs multiple checks with a single connection to Elasticsearch",
+	Long: `Runs multiple checks with a single connection to Elasticsearch
+
+The checks are read from a YAML file (or stdin) and run concurrently with a
+shared client, so that the connections and TLS sessions are reused. Each check
+takes the flags of its command, the global flags apply to all checks.
+
+Available checks: ` + strings.Join(slices.Sorted(maps.Keys(checkRunners)), ", ") + `
+
+By default the worst state of all checks is used as exit code and each check
+is shown in the long output. With --per-check one result line per check is printed.`,
+	Example: `
+$ cat checks.yml
+checks:
+  - name: cluster
+    check: health
+  - name: errors
+    check: query
+    args: ["--query", "log.level:error", "--warning", "10", "--critical", "50"]
+
+$ check_elasticsearch multi --file checks.yml
+[WARNING] - 1 of 2 checks are not OK
+ \_[OK] cluster: Cluster example is green
+ \_[WARNING] errors: Search query hits: 23
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		checks, err := loadChecks(cliMultiConfig.File)
+		if err != nil {
+			exitError(err)
+		}
+
+		ctx, cancel := timeoutContext()
+		defer cancel()
+
+		c, err := cliConfig.NewClient()
+		if err != nil {
+			exitError(err)
+		}
+
+		results := cliMultiConfig.runChecks(ctx, c, checks)
+
+		if !cliMultiConfig.PerCheck {
+			result := aggregateResults(checks, results)
+
+			cliConfig.checkFailover(c, result)
+
+			result.exit()
+		}
+
+		if cliIcingaConfig.Submit {
+			// Each check is submitted as the service with its name
+			for _, result := range results {
+				cliConfig.checkFailover(c, result)
+			}
+
+			names := make([]string, 0, len(checks))
+			for _, mcheck := range checks {
+				names = append(names, mcheck.name)
+			}
+
+			err = cliIcingaConfig.submitResults(results, names)
+			if err != nil {
+				check.ExitError(err)
+			}
+
+			check.BaseExit(check.OK)
+		}
+
+		states := make([]check.Status, 0, len(results))
+
+		for i, result := range results {
+			cliConfig.checkFailover(c, result)
+
+			result.Summary = checks[i].name + ": " + result.Summary
+			states = append(states, result.Status)
+
+			result.print()
+		}
+
+		check.BaseExit(check.WorstState(states...))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(multiCmd)
+
+	fs := multiCmd.Flags()
+	fs.StringVarP(&cliMultiConfig.File, "file", "f", "-",
+		"File with the check definitions, - reads from stdin")
+	fs.IntVar(&cliMultiConfig.Concurrency, "concurrency", 4,
+		"Maximum number of checks that run at the same time")
+	fs.BoolVar(&cliMultiConfig.PerCheck, "per-check", false,
+		"Print one result line per check instead of an aggregated result")
+
+	fs.SortFlags = false
+}
+
+// loadChecks reads the check definitions from a file
+func loadChecks(path string) ([]multiCheck, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read check file: %w", err)
+	}
+
+	var cf CheckFile
+
+	err = yaml.Unmarshal(data, &cf)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse check file %s: %w", path, err)
+	}
+
+	if len(cf.Checks) == 0 {
+		return nil, errors.New("no checks defined in check file " + path)
+	}
+
+	return parseChecks(cf.Checks)
+}
+
+// parseChecks parses the flags of each check definition
+func parseChecks(defs []CheckDefinition) ([]multiCheck, error) {
+	checks := make([]multiCheck, 0, len(defs))
+	names := make([]string, 0, len(defs))
+
+	for _, def := range defs {
+		newRunner, ok := checkRunners[def.Check]
+		if !ok {
+			return nil, fmt.Errorf("unknown check '%s'", def.Check)
+		}
+
+		name := def.Name
+		if name == "" {
+			name = def.Check
+		}
+
+		// The name is used as prefix of the perfdata labels
+		if slices.Contains(names, name) {
+			return nil, fmt.Errorf("duplicate check name '%s', set a unique name", name)
+		}
+
+		names = append(names, name)
+
+		runner := newRunner()
+
+		err := parseCheckArgs(def.Check, runner, def.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for check '%s': %w", name, err)
+		}
+
+		checks = append(checks, multiCheck{name: name, check: def.Check, runner: runner})
+	}
+
+	return checks, nil
+}
+
+// parseCheckArgs parses the flags of a check and validates them like its
+// command, e.g. flags that cannot be used together. Positional arguments
+// are not used by any check.
+func parseCheckArgs(name string, runner checkRunner, args []string) error {
+	checkCmd, _, err := rootCmd.Find([]string{name})
+	if err != nil {
+		return err
+	}
+
+	cmd := &cobra.Command{Use: name}
+
+	fs := cmd.Flags()
+	fs.SetOutput(io.Discard)
+	runner.addFlags(fs)
+
+	// The flag groups of the command are stored as annotations of its flags
+	checkCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if flag := fs.Lookup(f.Name); flag != nil {
+			flag.Annotations = f.Annotations
+		}
+	})
+
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument '%s'", fs.Arg(0))
+	}
+
+	err = cmd.ValidateRequiredFlags()
+	if err != nil {
+		return err
+	}
+
+	return cmd.ValidateFlagGroups()
+}
+
+// runChecks runs the checks with the shared client, at most
+// --concurrency at the same time. Checks that could not be
+// evaluated result in UNKNOWN.
+func (mc *MultiConfig) runChecks(ctx context.Context, c *client.Client, checks []multiCheck) []*Result {
+	results := make([]*Result, len(checks))
+	sem := make(chan struct{}, max(mc.Concurrency, 1))
+
+	var wg sync.WaitGroup
+
+	for i, mcheck := range checks {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result, err := mcheck.runner.run(ctx, c)
+			if err != nil {
+				result = &Result{Status: check.Unknown, Summary: err.Error()}
+			}
+
+			results[i] = result
+		})
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// aggregateResults combines the results of the checks, the worst state is used as
+// state of the aggregated result and the perfdata labels are prefixed with the check name
+func aggregateResults(checks []multiCheck, results []*Result) *Result {
+	var (
+		states   = make([]check.Status, 0, len(results))
+		items    = make([]Item, 0, len(results))
+		perfdata check.PerfdataList
+	)
+
+	for i, result := range results {
+		states = append(states, result.Status)
+
+		// The long output of a check is indented below its item
+		output := strings.TrimRight(result.Output(), "\n")
+		output = strings.ReplaceAll(output, "\n", "\n    ")
+
+		items = append(items, Item{Status: result.Status, Output: checks[i].name + ": " + output})
+
+		for _, p := range result.Perfdata {
+			pd := *p
+			pd.Label = checks[i].name + "::" + p.Label
+			perfdata.Add(&pd)
+		}
+	}
+
+	return &Result{
+		Status:   check.WorstState(states...),
+		Summary:  statesSummary(states, "checks"),
+		Items:    items,
+		Perfdata: perfdata,
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const multiChecks = `checks:
+  - name: cluster
+    check: health
+  - name: errors
+    check: query
+    args: ["--query", "log.level:error", "--warning", "10", "--critical", "50"]
+`
+
+func multiTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+
+		if strings.HasSuffix(r.URL.Path, "/_search") {
+			w.Write([]byte(`{"took":3,"timed_out":false,"hits":{"total":{"value":23,"relation":"eq"},"hits":[]}}`))
+			return
+		}
+
+		w.Write([]byte(`{"cluster_name":"test","status":"green","timed_out":false,"number_of_nodes":1,"number_of_data_nodes":1,"active_primary_shards":3,"active_shards":3,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":0,"delayed_unassigned_shards":0,"number_of_pending_tasks":0,"number_of_in_flight_fetch":0,"task_max_waiting_in_queue_millis":0,"active_shards_percent_as_number":100.0}`))
+	}))
+}
+
+func TestMulti_Aggregated(t *testing.T) {
+	server := multiTestServer()
+	defer server.Close()
+
+	// Count the connections to verify that the checks share them
+	var conns atomic.Int32
+
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "checks.yml")
+
+	err := os.WriteFile(file, []byte(multiChecks), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", "../main.go", "multi", "--file", file, "--concurrency", "1", "--hostname", server.URL)
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[WARNING] - 1 of 2 checks are not OK \n" +
+		" \\_[OK] cluster: Cluster test is green\n" +
+		" \\_[WARNING] errors: Search query hits: 23" +
+		"|cluster::nodes=1 cluster::data_nodes=1 cluster::active_primary_shards=3 cluster::active_shards=3 errors::query_hits=23c;10;50\n" +
+		"exit status 1\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	if conns.Load() != 1 {
+		t.Errorf("expected 1 connection, got %d", conns.Load())
+	}
+}
+
+func TestMulti_PerCheck(t *testing.T) {
+	server := multiTestServer()
+	defer server.Close()
+
+	cmd := exec.Command("go", "run", "../main.go", "multi", "--per-check", "--hostname", server.URL)
+	cmd.Stdin = strings.NewReader(multiChecks)
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[OK] - cluster: Cluster test is green|nodes=1 data_nodes=1 active_primary_shards=3 active_shards=3\n" +
+		"[WARNING] - errors: Search query hits: 23|query_hits=23c;10;50\n" +
+		"exit status 1\n"
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestMulti_InvalidChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   string
+		expected string
+	}{
+		{
+			name:     "unknown-check",
+			checks:   "checks:\n  - check: foo\n",
+			expected: "[UNKNOWN] - unknown check 'foo'",
+		},
+		{
+			name:     "duplicate-name",
+			checks:   "checks:\n  - check: health\n  - check: health\n",
+			expected: "[UNKNOWN] - duplicate check name 'health', set a unique name",
+		},
+		{
+			name:     "invalid-args",
+			checks:   "checks:\n  - check: health\n    args: [\"--foo\"]\n",
+			expected: "[UNKNOWN] - invalid arguments for check 'health': unknown flag: --foo",
+		},
+		{
+			name:     "exclusive-flags",
+			checks:   "checks:\n  - check: snapshot\n    args: [\"--number\", \"5\", \"--all\"]\n",
+			expected: "[UNKNOWN] - invalid arguments for check 'snapshot': if any flags in the group [number all] are set none of the others can be;",
+		},
+		{
+			name:     "positional-args",
+			checks:   "checks:\n  - check: health\n    args: [\"--aggregation\", \"best\", \"green\"]\n",
+			expected: "[UNKNOWN] - invalid arguments for check 'health': unexpected argument 'green'",
+		},
+		{
+			name:     "no-checks",
+			checks:   "checks: []\n",
+			expected: "[UNKNOWN] - no checks defined in check file -",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := exec.Command("go", "run", "../main.go", "multi", "--hostname", "http://localhost:9999")
+			cmd.Stdin = strings.NewReader(test.checks)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.Contains(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+		})
+	}
+}
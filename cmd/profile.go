@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// ConfigFile represents a configuration file with named connection profiles
+//
+//	profiles:
+//	  production:
+//	    hostnames:
+//	      - https://node1:9200
+//	      - https://node2:9200
+//	    username: monitoring
+//	    password: secret
+//	    ca_file: /etc/ssl/certs/elastic-ca.pem
+//	    timeout: 60
+type ConfigFile struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile stores the connection settings of a single cluster
+type Profile struct {
+	Hostnames    []string `yaml:"hostnames"`
+	Username     string   `yaml:"username"`
+	Password     string   `yaml:"password"`
+	PasswordFile string   `yaml:"password_file"`
+	Bearer       string   `yaml:"bearer"`
+	BearerFile   string   `yaml:"bearer_file"`
+	APIKey       string   `yaml:"api_key"`
+	APIKeyFile   string   `yaml:"api_key_file"`
+	CAFile       string   `yaml:"ca_file"`
+	CertFile     string   `yaml:"cert_file"`
+	KeyFile      string   `yaml:"key_file"`
+	Insecure     bool     `yaml:"insecure"`
+	Timeout      int      `yaml:"timeout"`
+}
+
+var (
+	configFile  string
+	profileName = "default"
+)
+
+// loadProfile reads the given profile from a configuration file
+func loadProfile(path string, name string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration file: %w", err)
+	}
+
+	var cf ConfigFile
+
+	err = yaml.Unmarshal(data, &cf)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s: %w", path, err)
+	}
+
+	p, ok := cf.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' not found in configuration file %s", name, path)
+	}
+
+	return &p, nil
+}
+
+// applyProfile sets the values of a profile in the Config.
+// Values set via CLI flags take precedence over the profile, which
+// itself takes precedence over the environment variables.
+func (c *Config) applyProfile(p *Profile, flags *pflag.FlagSet) {
+	setString := func(flag string, target *string, value string) {
+		if value != "" && !flags.Changed(flag) {
+			*target = value
+		}
+	}
+
+	// Secrets from files are only used when the secret is not set on the CLI
+	setSecretFile := func(flag string, target *string, value string) {
+		if !flags.Changed(flag) {
+			setString(flag+"-file", target, value)
+		}
+	}
+
+	if len(p.Hostnames) > 0 && !flags.Changed("hostname") {
+		c.Hostnames = p.Hostnames
+	}
+
+	setString("username", &c.Username, p.Username)
+	setString("password", &c.Password, p.Password)
+	setSecretFile("password", &c.PasswordFile, p.PasswordFile)
+	setString("bearer", &c.Bearer, p.Bearer)
+	setSecretFile("bearer", &c.BearerFile, p.BearerFile)
+	setString("api-key", &c.APIKey, p.APIKey)
+	setSecretFile("api-key", &c.APIKeyFile, p.APIKeyFile)
+	setString("ca-file", &c.CAFile, p.CAFile)
+	setString("cert-file", &c.CertFile, p.CertFile)
+	setString("key-file", &c.KeyFile, p.KeyFile)
+
+	if p.Insecure && !flags.Changed("insecure") {
+		c.Insecure = p.Insecure
+	}
+
+	if p.Timeout != 0 && !flags.Changed("timeout") {
+		timeout = p.Timeout
+	}
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
 	"github.com/NETWAYS/go-check"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type QueryConfig struct {
@@ -32,58 +35,7 @@ https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-query-
 	Example: "check_elasticsearch query -q \"event.dataset:sample_web_logs and @timestamp:[now-5m TO now]\" " +
 		"-I \"kibana_sample_data_logs\" -k \"message\"",
 	Run: func(_ *cobra.Command, _ []string) {
-		var (
-			rc     check.Status
-			output strings.Builder
-		)
-
-		client := cliConfig.NewClient()
-
-		total, messages, err := client.SearchMessages(
-			cliQueryConfig.Index,
-			cliQueryConfig.Query,
-			cliQueryConfig.MessageKey)
-		if err != nil {
-			check.ExitError(err)
-		}
-
-		fmt.Fprintf(&output, "Search query hits: %d", total)
-
-		if len(messages) > 0 {
-			output.WriteString("\n")
-
-			for _, msg := range messages {
-				if len(msg) > cliQueryConfig.MessageLen {
-					msg = msg[0:cliQueryConfig.MessageLen]
-				}
-
-				output.WriteString(msg + "\n")
-			}
-		}
-
-		crit, err := check.ParseThreshold(cliQueryConfig.Critical)
-		if err != nil {
-			check.ExitError(err)
-		}
-
-		warn, err := check.ParseThreshold(cliQueryConfig.Warning)
-		if err != nil {
-			check.ExitError(err)
-		}
-
-		if crit.DoesViolate(float64(total)) {
-			rc = check.Critical
-		} else if warn.DoesViolate(float64(total)) {
-			rc = check.Warning
-		} else {
-			rc = check.OK
-		}
-
-		p := check.PerfdataList{
-			{Label: "query_hits", Value: total, Warn: warn, Crit: crit, Uom: "c"},
-		}
-
-		check.ExitWithPerfdata(rc, p, output.String())
+		runCheck(&cliQueryConfig)
 	},
 }
 
@@ -91,18 +43,72 @@ func init() {
 	rootCmd.AddCommand(queryCmd)
 
 	fs := queryCmd.Flags()
-	fs.StringVarP(&cliQueryConfig.Query, "query", "q", "",
+	cliQueryConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (qc *QueryConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&qc.Query, "query", "q", "",
 		"The Elasticsearch query to run (query_string type syntax)")
-	fs.StringVarP(&cliQueryConfig.Index, "index", "I", "_all",
+	fs.StringVarP(&qc.Index, "index", "I", "_all",
 		"Name of the Index which will be used")
-	fs.StringVarP(&cliQueryConfig.MessageKey, "msgkey", "k", "",
+	fs.StringVarP(&qc.MessageKey, "msgkey", "k", "",
 		"Name of a field to display in the output (e.g. a message body)")
-	fs.IntVarP(&cliQueryConfig.MessageLen, "msglen", "m", 80,
+	fs.IntVarP(&qc.MessageLen, "msglen", "m", 80,
 		"Maximum number of characters to display from the requested field (default 80)")
-	fs.StringVarP(&cliQueryConfig.Warning, "warning", "w", "20",
+	fs.StringVarP(&qc.Warning, "warning", "w", "20",
 		"Warning threshold for total hits")
-	fs.StringVarP(&cliQueryConfig.Critical, "critical", "c", "50",
+	fs.StringVarP(&qc.Critical, "critical", "c", "50",
 		"Critical threshold for total hits")
+}
 
-	fs.SortFlags = false
+func (qc *QueryConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		rc      check.Status
+		details strings.Builder
+	)
+
+	total, messages, err := c.SearchMessages(ctx, qc.Index, qc.Query, qc.MessageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	crit, err := check.ParseThreshold(qc.Critical)
+	if err != nil {
+		return nil, err
+	}
+
+	warn, err := check.ParseThreshold(qc.Warning)
+	if err != nil {
+		return nil, err
+	}
+
+	if crit.DoesViolate(float64(total)) {
+		rc = check.Critical
+	} else if warn.DoesViolate(float64(total)) {
+		rc = check.Warning
+	} else {
+		rc = check.OK
+	}
+
+	if len(messages) > 0 {
+		details.WriteString("\n")
+
+		for _, msg := range messages {
+			if len(msg) > qc.MessageLen {
+				msg = msg[0:qc.MessageLen]
+			}
+
+			details.WriteString(msg + "\n")
+		}
+	}
+
+	return &Result{
+		Status:  rc,
+		Summary: fmt.Sprintf("Search query hits: %d", total),
+		Details: details.String(),
+		Perfdata: check.PerfdataList{
+			{Label: "query_hits", Value: total, Warn: warn, Crit: crit, Uom: "c"},
+		},
+	}, nil
 }
@@ -15,7 +15,7 @@ func TestQuery_ConnectionRefused(t *testing.T) {
 
 	actual := string(out)
 
-	expected := "[UNKNOWN] - could not execute search request: no node reachable (*errors.errorString)"
+	expected := "[UNKNOWN] - could not execute search request: no node answered successfully: http://localhost:9999: "
 
 	if !strings.Contains(actual, expected) {
 		t.Error("\nActual: ", actual, "\nExpected: ", expected)
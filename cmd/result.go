@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/pflag"
+)
+
+// Result is the outcome of a check. It is printed by the command of the
+// check or aggregated with the results of other checks by the multi command.
+type Result struct {
+	Status check.Status
+	// Summary is the first line of the output
+	Summary string
+	// Items are the lines of the long output, e.g. one per pipeline
+	Items []Item
+	// Details is additional text appended to the output as is
+	Details  string
+	Perfdata check.PerfdataList
+}
+
+// Item is a sub-result of a check, e.g. a single pipeline or snapshot
+type Item struct {
+	Status check.Status
+	Output string
+	// Informational items have no state, e.g. the nodes per version
+	Informational bool
+}
+
+// checkRunner is implemented by the configuration of each check, so that
+// the checks can be run by their command or by the multi command
+type checkRunner interface {
+	// addFlags registers the flags of the check with the configuration as target
+	addFlags(fs *pflag.FlagSet)
+	// run executes the check. Errors are returned when the check could not be evaluated.
+	run(ctx context.Context, c *client.Client) (*Result, error)
+}
+
+// checkRunners returns a new configuration for each check that can be run by the multi command
+var checkRunners = map[string]func() checkRunner{
+	"certificates":  func() checkRunner { return &CertificatesConfig{} },
+	"datastream":    func() checkRunner { return &DataStreamConfig{} },
+	"health":        func() checkRunner { return &HealthConfig{} },
+	"health-report": func() checkRunner { return &HealthReportConfig{} },
+	"index":         func() checkRunner { return &IndexConfig{} },
+	"ingest":        func() checkRunner { return &PipelineConfig{} },
+	"license":       func() checkRunner { return &LicenseConfig{} },
+	"mappings":      func() checkRunner { return &MappingsConfig{} },
+	"query":         func() checkRunner { return &QueryConfig{} },
+	"security-keys": func() checkRunner { return &SecurityKeysConfig{} },
+	"shards":        func() checkRunner { return &ShardsConfig{} },
+	"snapshot":      func() checkRunner { return &SnapshotConfig{} },
+	"version":       func() checkRunner { return &VersionConfig{} },
+}
+
+// runCheck runs a check with the global configuration and exits with its result
+func runCheck(r checkRunner) {
+	ctx, cancel := timeoutContext()
+	defer cancel()
+
+	var result *Result
+
+	c, err := cliConfig.NewClient()
+	if err == nil {
+		result, err = r.run(ctx, c)
+	}
+
+	if err != nil {
+		if outputFormat == "json" || cliIcingaConfig.Submit {
+			result = &Result{Status: check.Unknown, Summary: err.Error()}
+			result.exit()
+		}
+
+		check.ExitError(err)
+	}
+
+	cliConfig.checkFailover(c, result)
+
+	result.exit()
+}
+
+// exitError exits with UNKNOWN for errors before a check is run, e.g. invalid
+// flags. With --output json the error is printed as result document.
+func exitError(err error) {
+	if outputFormat == "json" {
+		result := &Result{Status: check.Unknown, Summary: err.Error()}
+		result.print()
+
+		check.BaseExit(result.Status)
+	}
+
+	check.ExitError(err)
+}
+
+// statesSummary returns a summary of the states of multiple objects, e.g. "1 of 2 indices are not OK"
+func statesSummary(states []check.Status, objects string) string {
+	notOK := 0
+
+	for _, s := range states {
+		if s != check.OK {
+			notOK++
+		}
+	}
+
+	if notOK > 0 {
+		return fmt.Sprintf("%d of %d %s are not OK", notOK, len(states), objects)
+	}
+
+	return fmt.Sprintf("All %d %s are OK", len(states), objects)
+}
+
+// Output returns the plugin output without the state and perfdata
+func (r *Result) Output() string {
+	var output strings.Builder
+
+	output.WriteString(r.Summary)
+
+	if len(r.Items) > 0 {
+		output.WriteString(" ")
+
+		for _, item := range r.Items {
+			output.WriteString("\n \\_" + item.String())
+		}
+	}
+
+	output.WriteString(r.Details)
+
+	return output.String()
+}
+
+// validPerfdata reports whether the perfdata is part of the plugin output, values
+// that are not valid perfdata (e.g. infinite) are omitted from every output format
+func validPerfdata(p *check.Perfdata) bool {
+	_, err := p.ValidatedString()
+
+	return err == nil
+}
+
+// pluginOutput returns the state and output without the perfdata
+func (r *Result) pluginOutput() string {
+	return "[" + r.Status.String() + "] - " + strings.ReplaceAll(r.Output(), check.PerfdataSeparatorSymbol, " ")
+}
+
+// String returns the plugin output in the format of check.ExitWithPerfdata
+func (r *Result) String() string {
+	return r.pluginOutput() + check.PerfdataSeparatorSymbol + r.Perfdata.String()
+}
+
+// String returns the item as line of the long output
+func (i Item) String() string {
+	if i.Informational {
+		return " " + i.Output
+	}
+
+	return "[" + i.Status.String() + "] " + i.Output
+}
+
+// jsonResult is the document printed with --output json
+type jsonResult struct {
+	State    string       `json:"state"`
+	ExitCode int          `json:"exit_code"`
+	Summary  string       `json:"summary"`
+	Details  string       `json:"details,omitempty"`
+	Items    []jsonItem   `json:"items"`
+	Metrics  []jsonMetric `json:"metrics"`
+}
+
+type jsonItem struct {
+	State  string `json:"state,omitempty"`
+	Output string `json:"output"`
+}
+
+type jsonMetric struct {
+	Label    string `json:"label"`
+	Value    any    `json:"value"`
+	Unit     string `json:"unit,omitempty"`
+	Warning  string `json:"warning,omitempty"`
+	Critical string `json:"critical,omitempty"`
+	Min      any    `json:"min,omitempty"`
+	Max      any    `json:"max,omitempty"`
+}
+
+// JSON returns the result as JSON document, the thresholds of the
+// metrics use the range format of the perfdata
+func (r *Result) JSON() []byte {
+	doc := jsonResult{
+		State:    r.Status.String(),
+		ExitCode: int(r.Status),
+		Summary:  r.Summary,
+		Details:  strings.TrimSpace(r.Details),
+		Items:    make([]jsonItem, 0, len(r.Items)),
+		Metrics:  make([]jsonMetric, 0, len(r.Perfdata)),
+	}
+
+	for _, item := range r.Items {
+		ji := jsonItem{Output: item.Output}
+		if !item.Informational {
+			ji.State = item.Status.String()
+		}
+
+		doc.Items = append(doc.Items, ji)
+	}
+
+	for _, p := range r.Perfdata {
+		if !validPerfdata(p) {
+			continue
+		}
+
+		m := jsonMetric{Label: p.Label, Value: p.Value, Unit: p.Uom, Min: p.Min, Max: p.Max}
+
+		if p.Warn != nil {
+			m.Warning = p.Warn.String()
+		}
+
+		if p.Crit != nil {
+			m.Critical = p.Crit.String()
+		}
+
+		doc.Metrics = append(doc.Metrics, m)
+	}
+
+	// The document only contains types that can be marshalled
+	data, _ := json.Marshal(doc)
+
+	return data
+}
+
+// print writes the result in the --output format to stdout
+func (r *Result) print() {
+	if outputFormat == "json" {
+		_, _ = os.Stdout.Write(append(r.JSON(), '\n'))
+	} else {
+		_, _ = os.Stdout.WriteString(r.String() + "\n")
+	}
+}
+
+// exit prints the result and exits with its state, or submits
+// the result with --submit-icinga
+func (r *Result) exit() {
+	if cliIcingaConfig.Submit {
+		cliIcingaConfig.submit(r)
+	}
+
+	r.print()
+
+	check.BaseExit(r.Status)
+}
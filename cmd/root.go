@@ -1,21 +1,67 @@
 package cmd
 
 import (
+	"errors"
 	"os"
+	"time"
 
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
 	"github.com/NETWAYS/go-check"
 	"github.com/spf13/cobra"
 )
 
 var (
-	timeout = 30
+	timeout      = 30
+	outputFormat = "text"
 )
 
+// annotationNoTimeout marks long running commands, for which the
+// --timeout does not apply to the whole process
+const annotationNoTimeout = "no-timeout"
+
 var rootCmd = &cobra.Command{
 	Use:   "check_elasticsearch",
 	Short: "Icinga check plugin to check Elasticsearch",
-	PersistentPreRun: func(_ *cobra.Command, _ []string) {
-		go check.HandleTimeout(timeout)
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		if outputFormat != "text" && outputFormat != "json" {
+			check.ExitError(errors.New("invalid value for --output: " + outputFormat))
+		}
+
+		// The environment is read after the flags, so that flags take precedence
+		// and errors do not prevent --help
+		err := loadFromEnv(&cliConfig, cmd.Flags())
+		if err != nil {
+			exitError(err)
+		}
+
+		err = loadFromEnv(&cliIcingaConfig, cmd.Flags())
+		if err != nil {
+			exitError(err)
+		}
+
+		err = cliIcingaConfig.validate()
+		if err != nil {
+			exitError(err)
+		}
+
+		if cmd.Flags().Changed("profile") && configFile == "" {
+			exitError(errors.New("--profile requires a configuration file (--config)"))
+		}
+
+		if configFile != "" {
+			p, err := loadProfile(configFile, profileName)
+			if err != nil {
+				exitError(err)
+			}
+
+			cliConfig.applyProfile(p, cmd.Flags())
+		}
+
+		if cmd.Annotations[annotationNoTimeout] == "" {
+			timeoutDeadline = time.Now().Add(time.Duration(timeout) * time.Second)
+
+			go check.HandleTimeout(timeout)
+		}
 	},
 	Run: Help,
 }
@@ -28,7 +74,7 @@ func Execute(version string) {
 
 	err := rootCmd.Execute()
 	if err != nil {
-		check.ExitError(err)
+		exitError(err)
 	}
 }
 
@@ -48,10 +94,18 @@ func init() {
 		"Username for HTTP Basic Authentication (CHECK_ELASTICSEARCH_USERNAME)")
 	pfs.StringVarP(&cliConfig.Password, "password", "P", "",
 		"Password for HTTP Basic Authentication (CHECK_ELASTICSEARCH_PASSWORD)")
+	pfs.StringVar(&cliConfig.PasswordFile, "password-file", "",
+		"File containing the password for HTTP Basic Authentication (CHECK_ELASTICSEARCH_PASSWORD_FILE)")
 	pfs.StringVarP(&cliConfig.Bearer, "bearer", "b", "",
 		"Specify the Bearer Token for authentication (CHECK_ELASTICSEARCH_BEARER)")
+	pfs.StringVar(&cliConfig.BearerFile, "bearer-file", "",
+		"File containing the Bearer Token for authentication (CHECK_ELASTICSEARCH_BEARER_FILE)")
+	pfs.StringVar(&cliConfig.APIKey, "api-key", "",
+		"Specify the API Key for authentication, either base64 encoded or as id:key (CHECK_ELASTICSEARCH_API_KEY)")
+	pfs.StringVar(&cliConfig.APIKeyFile, "api-key-file", "",
+		"File containing the API Key for authentication (CHECK_ELASTICSEARCH_API_KEY_FILE)")
 	pfs.BoolVar(&cliConfig.Insecure, "insecure", false,
-		"Skip the verification of the server's TLS certificate")
+		"Skip the verification of the server's TLS certificate (CHECK_ELASTICSEARCH_INSECURE)")
 	pfs.StringVarP(&cliConfig.CAFile, "ca-file", "", "",
 		"Specify the CA File for TLS authentication (CHECK_ELASTICSEARCH_CA_FILE)")
 	pfs.StringVarP(&cliConfig.CertFile, "cert-file", "", "",
@@ -60,11 +114,36 @@ func init() {
 		"Specify the Key File for TLS authentication (CHECK_ELASTICSEARCH_KEY_FILE)")
 	pfs.IntVarP(&timeout, "timeout", "t", timeout,
 		"Timeout in seconds for the plugin")
+	pfs.IntVar(&cliConfig.Retries, "retries", 0,
+		"Number of retries on all nodes when no node answered, on connection errors or a --failover-status")
+	pfs.DurationVar(&cliConfig.RetryBackoff, "retry-backoff", time.Second,
+		"Time to wait before the first retry, doubled for every further retry")
+	pfs.Var(newStatusCodesValue(client.DefaultFailoverStatus, &cliConfig.FailoverStatus), "failover-status",
+		"HTTP status codes on which the next node is tried, an empty value disables the failover on status codes. Can be used multiple times")
+	pfs.BoolVar(&cliConfig.FailoverWarning, "failover-warning", false,
+		"Return WARNING when a node failed and another node answered")
+	pfs.BoolVar(&cliConfig.Sniff, "sniff", false,
+		"Discover the other nodes of the cluster after the first request and use them for failover")
+	pfs.StringSliceVar(&cliConfig.SniffRoles, "sniff-role", []string{},
+		"Only use discovered nodes with one of these roles (e.g. data, ingest). Default: all except master-only nodes")
+	pfs.BoolVar(&cliConfig.SkipProductCheck, "skip-product-check", false,
+		"Skip the verification that the server is Elasticsearch")
+	pfs.StringVar(&outputFormat, "output", outputFormat,
+		"Output format of the check result (text, json)")
+	pfs.StringVar(&configFile, "config", "",
+		"Path to a YAML configuration file with connection profiles. CLI flags take precedence over the profile")
+	pfs.StringVar(&profileName, "profile", profileName,
+		"Name of the connection profile in the configuration file")
+
+	cliIcingaConfig.addFlags(pfs)
+
+	rootCmd.MarkFlagsMutuallyExclusive("password", "password-file")
+	rootCmd.MarkFlagsMutuallyExclusive("bearer", "bearer-file")
+	rootCmd.MarkFlagsMutuallyExclusive("api-key", "api-key-file")
 
 	rootCmd.Flags().SortFlags = false
 	pfs.SortFlags = false
 
-	loadFromEnv(&cliConfig)
 }
 
 func Help(cmd *cobra.Command, _ []string) {
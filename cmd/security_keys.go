@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// SecurityKeysConfig stores the CLI parameters.
+type SecurityKeysConfig struct {
+	Owner             bool
+	Realm             string
+	Username          string
+	Names             []string
+	WarningDays       int
+	CriticalDays      int
+	NoExpirationState string
+}
+
+const securityKeysOutput = "API key %s (id: %s, user: %s): %s"
+
+var cliSecurityKeysConfig SecurityKeysConfig
+
+var securityKeysCmd = &cobra.Command{
+	Use:   "security-keys",
+	Short: "Checks the expiry of Elasticsearch API keys",
+	Long: `Checks the expiry of Elasticsearch API keys
+
+The plugin alerts when:
+	an API key is expired but not invalidated = CRITICAL
+	an API key expires within the given number of days = WARNING/CRITICAL
+	an API key has no expiration = --no-expiration-state
+
+Invalidated API keys are ignored.`,
+	Example: `
+$ check_elasticsearch security-keys --realm native --realm-user beats
+[OK] - All 3 API keys are valid for at least 30 days
+
+$ check_elasticsearch security-keys --name "^beats-" --no-expiration-state WARNING
+[CRITICAL] - API keys not alright: 1 expired, 0 expiring within 30 days, 1 without expiration
+ \_[CRITICAL] API key beats-prod (id: VuaCfGcBCdbkQm-e5aOx, user: beats): expired 3 days ago (2025-10-16)
+ \_[WARNING] API key beats-dev (id: H3_AhoIBA9hmeQJdg7ij, user: beats): does not expire
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliSecurityKeysConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(securityKeysCmd)
+
+	fs := securityKeysCmd.Flags()
+	cliSecurityKeysConfig.addFlags(fs)
+
+	securityKeysCmd.MarkFlagsMutuallyExclusive("owner", "realm")
+	securityKeysCmd.MarkFlagsMutuallyExclusive("owner", "realm-user")
+
+	fs.SortFlags = false
+}
+
+func (sc *SecurityKeysConfig) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&sc.Owner, "owner", false,
+		"Only check the API keys owned by the authenticated user")
+	fs.StringVar(&sc.Realm, "realm", "",
+		"Only check the API keys of the given realm")
+	fs.StringVar(&sc.Username, "realm-user", "",
+		"Only check the API keys of the given user")
+	fs.StringArrayVar(&sc.Names, "name", []string{},
+		"Name of the API key to check. Can be used multiple times and supports regex.")
+	fs.IntVarP(&sc.WarningDays, "warning", "w", 30,
+		"Warning threshold for the remaining days until an API key expires")
+	fs.IntVarP(&sc.CriticalDays, "critical", "c", 7,
+		"Critical threshold for the remaining days until an API key expires")
+	fs.StringVar(&sc.NoExpirationState, "no-expiration-state", "OK",
+		"State to assign to API keys without expiration (OK, WARNING, CRITICAL, UNKNOWN)")
+}
+
+func (sc *SecurityKeysConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	noExpirationState, err := check.NewStatusFromString(sc.NoExpirationState)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for --no-expiration-state: %s", sc.NoExpirationState)
+	}
+
+	warn := expiryThreshold(sc.WarningDays)
+	crit := expiryThreshold(sc.CriticalDays)
+
+	keys, err := c.APIKeys(ctx, sc.Owner, sc.Realm, sc.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		states []check.Status
+		items  []Item
+
+		expired      int
+		expiring     int
+		noExpiration int
+	)
+
+	for _, key := range keys.APIKeys {
+		if key.Invalidated {
+			continue
+		}
+
+		keyMatched, regexErr := matches(key.Name, sc.Names)
+		if regexErr != nil {
+			return &Result{Status: check.Unknown, Summary: "Invalid regular expression provided: " + regexErr.Error()}, nil
+		}
+
+		if !keyMatched && len(sc.Names) >= 1 {
+			// If the key doesn't match a regex from the list we can skip it.
+			continue
+		}
+
+		var (
+			rc      check.Status
+			expires string
+		)
+
+		if key.Expiration == 0 {
+			noExpiration++
+
+			rc = noExpirationState
+			expires = "does not expire"
+		} else {
+			expiry := time.UnixMilli(key.Expiration)
+			remainingDays := math.Floor(time.Until(expiry).Hours() / 24)
+
+			expires = fmt.Sprintf("expires in %g days (%s)", remainingDays, expiry.Format(time.DateOnly))
+
+			switch {
+			case remainingDays < 0:
+				// Expired keys that are not invalidated can still be used
+				expired++
+
+				rc = check.Critical
+				expires = fmt.Sprintf("expired %g days ago (%s)", -remainingDays, expiry.Format(time.DateOnly))
+			case crit.DoesViolate(remainingDays):
+				expiring++
+
+				rc = check.Critical
+			case warn.DoesViolate(remainingDays):
+				expiring++
+
+				rc = check.Warning
+			default:
+				rc = check.OK
+			}
+		}
+
+		states = append(states, rc)
+
+		items = append(items, Item{Status: rc, Output: fmt.Sprintf(securityKeysOutput, key.Name, key.ID, key.Username, expires)})
+	}
+
+	if len(states) == 0 {
+		return &Result{Status: check.OK, Summary: "No API keys found"}, nil
+	}
+
+	var output string
+
+	rc := check.WorstState(states...)
+
+	if rc == check.OK {
+		output = fmt.Sprintf("All %d API keys are valid for at least %d days", len(states), sc.WarningDays)
+	} else {
+		output = fmt.Sprintf("API keys not alright: %d expired, %d expiring within %d days, %d without expiration",
+			expired, expiring, sc.WarningDays, noExpiration)
+	}
+
+	return &Result{
+		Status:  rc,
+		Summary: output,
+		Items:   items,
+		Perfdata: check.PerfdataList{
+			{Label: "api_keys", Value: len(states)},
+			{Label: "expired", Value: expired},
+			{Label: "expiring", Value: expiring},
+			{Label: "no_expiration", Value: noExpiration},
+		},
+	}, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type SecurityKeysTest struct {
+	name     string
+	server   *httptest.Server
+	args     []string
+	expected string
+}
+
+func TestSecurityKeysCmd(t *testing.T) {
+	tests := []SecurityKeysTest{
+		{
+			name: "security-keys-ok",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"api_keys":[{"id":"VuaCfGcBCdbkQm-e5aOx","name":"beats-prod","creation":1548550550158,"expiration":4102444800000,"invalidated":false,"username":"beats","realm":"native1"},{"id":"H3_AhoIBA9hmeQJdg7ij","name":"old","creation":1548550550158,"expiration":1593093628850,"invalidated":true,"username":"beats","realm":"native1"}]}`))
+			})),
+			args:     []string{"run", "../main.go", "security-keys"},
+			expected: "[OK] - All 1 API keys are valid for at least 30 days \n \\_[OK] API key beats-prod (id: VuaCfGcBCdbkQm-e5aOx, user: beats): expires in ",
+		},
+		{
+			name: "security-keys-expired",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"api_keys":[{"id":"VuaCfGcBCdbkQm-e5aOx","name":"beats-prod","creation":1548550550158,"expiration":1593093628850,"invalidated":false,"username":"beats","realm":"native1"},{"id":"H3_AhoIBA9hmeQJdg7ij","name":"beats-dev","creation":1548550550158,"invalidated":false,"username":"beats","realm":"native1"}]}`))
+			})),
+			args:     []string{"run", "../main.go", "security-keys"},
+			expected: "[CRITICAL] - API keys not alright: 1 expired, 0 expiring within 30 days, 1 without expiration",
+		},
+		{
+			name: "security-keys-no-expiration",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"api_keys":[{"id":"VuaCfGcBCdbkQm-e5aOx","name":"beats-prod","creation":1548550550158,"expiration":1593093628850,"invalidated":false,"username":"beats","realm":"native1"},{"id":"H3_AhoIBA9hmeQJdg7ij","name":"beats-dev","creation":1548550550158,"invalidated":false,"username":"beats","realm":"native1"}]}`))
+			})),
+			args:     []string{"run", "../main.go", "security-keys", "--name", "dev$", "--no-expiration-state", "WARNING"},
+			expected: "[WARNING] - API keys not alright: 0 expired, 0 expiring within 30 days, 1 without expiration \n \\_[WARNING] API key beats-dev (id: H3_AhoIBA9hmeQJdg7ij, user: beats): does not expire|api_keys=1 expired=0 expiring=0 no_expiration=1\n",
+		},
+		{
+			name: "security-keys-owner",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				if r.URL.Query().Get("owner") != "true" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"api_keys":[]}`))
+			})),
+			args:     []string{"run", "../main.go", "security-keys", "--owner"},
+			expected: "[OK] - No API keys found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			cmd := exec.Command("go", append(test.args, "--hostname", test.server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.Contains(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ShardsConfig stores the CLI parameters.
+type ShardsConfig struct {
+	SizeWarning       string
+	SizeCritical      string
+	NodeWarning       string
+	NodeCritical      string
+	HeapRatioWarning  string
+	HeapRatioCritical string
+	Top               int
+}
+
+// defaultMaxShardsPerNode is the default of cluster.max_shards_per_node
+const defaultMaxShardsPerNode = 1000
+
+// shardInfo is an assigned shard with its size
+type shardInfo struct {
+	name string
+	node string
+	size uint64
+}
+
+var cliShardsConfig ShardsConfig
+
+var shardsCmd = &cobra.Command{
+	Use:   "shards",
+	Short: "Checks the size and count of the shards of an Elasticsearch cluster",
+	Long: `Checks the size and count of the shards of an Elasticsearch cluster
+
+The size thresholds apply to each shard and the largest shards are listed. For
+each node holding shards the number of shards is checked in percent of the
+cluster.max_shards_per_node setting and relative to the heap of the node, in
+shards per GB heap. Relocating shards count on the source and the target node.
+Sizes support the units B, KB, MB, GB, TB and PB.`,
+	Example: `
+$ check_elasticsearch shards --size-warning 50GB --top 2
+[WARNING] - 1500 shards on 2 nodes, 1 shards exceed the size thresholds
+ \_[OK] node1: 800 shards (80% of cluster.max_shards_per_node 1000), 25.0 shards per GB heap
+ \_[OK] node2: 700 shards (70% of cluster.max_shards_per_node 1000), 21.9 shards per GB heap
+ \_[WARNING] logs-2024.01.01[0] on node1: 72.5GB
+ \_[OK] logs-2024.01.02[3] on node2: 48.1GB
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliShardsConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shardsCmd)
+
+	fs := shardsCmd.Flags()
+	cliShardsConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (sc *ShardsConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&sc.SizeWarning, "size-warning", "50GB",
+		"Warning threshold for the size of a shard. Use min:max for a range.")
+	fs.StringVar(&sc.SizeCritical, "size-critical", "100GB",
+		"Critical threshold for the size of a shard. Use min:max for a range.")
+	fs.StringVar(&sc.NodeWarning, "node-warning", "80",
+		"Warning threshold for the shards of a node in percent of cluster.max_shards_per_node")
+	fs.StringVar(&sc.NodeCritical, "node-critical", "90",
+		"Critical threshold for the shards of a node in percent of cluster.max_shards_per_node")
+	fs.StringVar(&sc.HeapRatioWarning, "heap-ratio-warning", "",
+		"Warning threshold for the shards of a node per GB heap (e.g. 20)")
+	fs.StringVar(&sc.HeapRatioCritical, "heap-ratio-critical", "",
+		"Critical threshold for the shards of a node per GB heap (e.g. 25)")
+	fs.IntVar(&sc.Top, "top", 5,
+		"Number of the largest shards to list")
+}
+
+func (sc *ShardsConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var (
+		items    []Item
+		perfList check.PerfdataList
+	)
+
+	var (
+		sizeWarn, sizeCrit, nodeWarn, nodeCrit, heapWarn, heapCrit *check.Threshold
+		err                                                        error
+	)
+
+	parsers := []struct {
+		threshold **check.Threshold
+		value     string
+		size      bool
+	}{
+		{&sizeWarn, sc.SizeWarning, true},
+		{&sizeCrit, sc.SizeCritical, true},
+		{&nodeWarn, sc.NodeWarning, false},
+		{&nodeCrit, sc.NodeCritical, false},
+		{&heapWarn, sc.HeapRatioWarning, false},
+		{&heapCrit, sc.HeapRatioCritical, false},
+	}
+
+	for _, p := range parsers {
+		*p.threshold, err = parseOptionalThreshold(p.value, p.size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shards, err := c.CatShards(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := c.ClusterSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	maxShardsPerNode := defaultMaxShardsPerNode
+
+	if value, ok := settings.Setting("cluster.max_shards_per_node"); ok {
+		maxShardsPerNode, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster.max_shards_per_node: %w", err)
+		}
+	}
+
+	stats, err := c.NodeStats(ctx, "jvm")
+	if err != nil {
+		return nil, err
+	}
+
+	heaps := make(map[string]int64, len(stats.Nodes))
+	for _, node := range stats.Nodes {
+		heaps[node.Name] = node.JVM.Mem.HeapMaxInBytes
+	}
+
+	var (
+		assigned   []shardInfo
+		unassigned int
+	)
+
+	nodeShards := map[string]int{}
+
+	for _, shard := range shards {
+		// The node of a relocating shard is given as "source -> address id target",
+		// the shard counts on both nodes until the relocation is finished
+		nodeNames := strings.Fields(shard.Node)
+		if len(nodeNames) == 0 {
+			unassigned++
+			continue
+		}
+
+		node := nodeNames[0]
+		nodeShards[node]++
+
+		if len(nodeNames) > 1 && nodeNames[1] == "->" {
+			nodeShards[nodeNames[len(nodeNames)-1]]++
+		}
+
+		size, _ := strconv.ParseUint(shard.Store, 10, 64)
+		assigned = append(assigned, shardInfo{name: shard.Index + "[" + shard.Shard + "]", node: node, size: size})
+	}
+
+	states := []check.Status{check.OK}
+
+	// Nodes
+	nodes := make([]string, 0, len(nodeShards))
+	for node := range nodeShards {
+		nodes = append(nodes, node)
+	}
+
+	slices.Sort(nodes)
+
+	for _, node := range nodes {
+		count := nodeShards[node]
+		usage := float64(count) * 100 / float64(maxShardsPerNode)
+
+		state := thresholdState(usage, nodeWarn, nodeCrit)
+		output := fmt.Sprintf("%s: %d shards (%.0f%% of cluster.max_shards_per_node %d)", node, count, usage, maxShardsPerNode)
+
+		perfList.Add(&check.Perfdata{Label: "nodes." + node + ".shards", Value: count})
+		perfList.Add(&check.Perfdata{Label: "nodes." + node + ".shards_usage", Value: usage, Uom: "%", Warn: nodeWarn, Crit: nodeCrit})
+
+		if heap := heaps[node]; heap > 0 {
+			ratio := float64(count) / (float64(heap) / (1 << 30))
+
+			state = max(state, thresholdState(ratio, heapWarn, heapCrit))
+			output += fmt.Sprintf(", %.1f shards per GB heap", ratio)
+
+			perfList.Add(&check.Perfdata{Label: "nodes." + node + ".shards_per_heap_gb", Value: ratio, Warn: heapWarn, Crit: heapCrit})
+		}
+
+		states = append(states, state)
+		items = append(items, Item{Status: state, Output: output})
+	}
+
+	// Largest shards
+	slices.SortStableFunc(assigned, func(a, b shardInfo) int {
+		return cmp.Compare(b.size, a.size)
+	})
+
+	oversized := 0
+
+	for i, shard := range assigned {
+		state := thresholdState(float64(shard.size), sizeWarn, sizeCrit)
+		if state != check.OK {
+			oversized++
+		}
+
+		states = append(states, state)
+
+		if i < sc.Top {
+			items = append(items, Item{Status: state, Output: fmt.Sprintf("%s on %s: %s", shard.name, shard.node, formatBytes(float64(shard.size)))})
+		}
+	}
+
+	var largest uint64
+	if len(assigned) > 0 {
+		largest = assigned[0].size
+	}
+
+	perfList.Add(&check.Perfdata{Label: "shards", Value: len(shards)})
+	perfList.Add(&check.Perfdata{Label: "shards.unassigned", Value: unassigned})
+	perfList.Add(&check.Perfdata{Label: "shards.oversized", Value: oversized})
+	perfList.Add(&check.Perfdata{Label: "shards.largest", Value: largest, Uom: "B", Warn: sizeWarn, Crit: sizeCrit})
+
+	summary := fmt.Sprintf("%d shards on %d nodes", len(shards), len(nodes))
+
+	if oversized > 0 {
+		summary += fmt.Sprintf(", %d shards exceed the size thresholds", oversized)
+	}
+
+	if unassigned > 0 {
+		summary += fmt.Sprintf(", %d unassigned", unassigned)
+	}
+
+	return &Result{Status: check.WorstState(states...), Summary: summary, Items: items, Perfdata: perfList}, nil
+}
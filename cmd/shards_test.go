@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShards_ConnectionRefused(t *testing.T) {
+	cmd := exec.Command("go", "run", "../main.go", "shards", "--hostname", "http://localhost:9999")
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[UNKNOWN] - could not fetch shards: no node answered successfully: http://localhost:9999: "
+
+	if !strings.Contains(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func newShardsServer(settings string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+
+		switch r.URL.Path {
+		case "/_cat/shards":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"index":"logs","shard":"0","prirep":"p","state":"STARTED","store":"64424509440","node":"node1"},` +
+				`{"index":"logs","shard":"0","prirep":"r","state":"RELOCATING","store":"64424509440","node":"node2 -> 10.0.0.3 abc node3"},` +
+				`{"index":"metrics","shard":"0","prirep":"p","state":"STARTED","store":"1073741824","node":"node2"},` +
+				`{"index":"metrics","shard":"0","prirep":"r","state":"UNASSIGNED","store":null,"node":null}]`))
+		case "/_cluster/settings":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(settings))
+		case "/_nodes/stats/jvm":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"nodes":{"a":{"name":"node1","jvm":{"mem":{"heap_max_in_bytes":1073741824}}},"b":{"name":"node2","jvm":{"mem":{"heap_max_in_bytes":2147483648}}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestShardsCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "shards-ok",
+			settings: `{"persistent":{},"transient":{},"defaults":{"cluster.max_shards_per_node":"1000"}}`,
+			args:     []string{"run", "../main.go", "shards", "--size-warning", "100GB", "--size-critical", "200GB", "--top", "1"},
+			expected: "[OK] - 4 shards on 3 nodes, 1 unassigned \n \\_[OK] node1: 1 shards (0% of cluster.max_shards_per_node 1000), 1.0 shards per GB heap\n \\_[OK] node2: 2 shards (0% of cluster.max_shards_per_node 1000), 1.0 shards per GB heap\n \\_[OK] node3: 1 shards (0% of cluster.max_shards_per_node 1000)\n \\_[OK] logs[0] on node1: 60.0GB|nodes.node1.shards=1 nodes.node1.shards_usage=0.1%;80;90 nodes.node1.shards_per_heap_gb=1 nodes.node2.shards=2 nodes.node2.shards_usage=0.2%;80;90 nodes.node2.shards_per_heap_gb=1 nodes.node3.shards=1 nodes.node3.shards_usage=0.1%;80;90 shards=4 shards.unassigned=1 shards.oversized=0 shards.largest=64424509440B;107374182400;214748364800\n",
+		},
+		{
+			name:     "shards-size-warning",
+			settings: `{"persistent":{},"transient":{},"defaults":{"cluster.max_shards_per_node":"1000"}}`,
+			args:     []string{"run", "../main.go", "shards", "--top", "3"},
+			expected: "[WARNING] - 4 shards on 3 nodes, 2 shards exceed the size thresholds, 1 unassigned \n \\_[OK] node1: 1 shards (0% of cluster.max_shards_per_node 1000), 1.0 shards per GB heap\n \\_[OK] node2: 2 shards (0% of cluster.max_shards_per_node 1000), 1.0 shards per GB heap\n \\_[OK] node3: 1 shards (0% of cluster.max_shards_per_node 1000)\n \\_[WARNING] logs[0] on node1: 60.0GB\n \\_[WARNING] logs[0] on node2: 60.0GB\n \\_[OK] metrics[0] on node2: 1.0GB|nodes.node1.shards=1 nodes.node1.shards_usage=0.1%;80;90 nodes.node1.shards_per_heap_gb=1 nodes.node2.shards=2 nodes.node2.shards_usage=0.2%;80;90 nodes.node2.shards_per_heap_gb=1 nodes.node3.shards=1 nodes.node3.shards_usage=0.1%;80;90 shards=4 shards.unassigned=1 shards.oversized=2 shards.largest=64424509440B;53687091200;107374182400\nexit status 1\n",
+		},
+		{
+			name:     "shards-max-shards-per-node",
+			settings: `{"persistent":{"cluster.max_shards_per_node":"2"},"transient":{},"defaults":{"cluster.max_shards_per_node":"1000"}}`,
+			args:     []string{"run", "../main.go", "shards", "--size-warning", "", "--size-critical", "", "--top", "0"},
+			expected: "[CRITICAL] - 4 shards on 3 nodes, 1 unassigned \n \\_[OK] node1: 1 shards (50% of cluster.max_shards_per_node 2), 1.0 shards per GB heap\n \\_[CRITICAL] node2: 2 shards (100% of cluster.max_shards_per_node 2), 1.0 shards per GB heap\n \\_[OK] node3: 1 shards (50% of cluster.max_shards_per_node 2)|nodes.node1.shards=1 nodes.node1.shards_usage=50%;80;90 nodes.node1.shards_per_heap_gb=1 nodes.node2.shards=2 nodes.node2.shards_usage=100%;80;90 nodes.node2.shards_per_heap_gb=1 nodes.node3.shards=1 nodes.node3.shards_usage=50%;80;90 shards=4 shards.unassigned=1 shards.oversized=0 shards.largest=64424509440B\nexit status 2\n",
+		},
+		{
+			name:     "shards-heap-ratio",
+			settings: `{"persistent":{},"transient":{},"defaults":{"cluster.max_shards_per_node":"1000"}}`,
+			args:     []string{"run", "../main.go", "shards", "--size-warning", "", "--size-critical", "", "--heap-ratio-warning", "0.5", "--top", "0"},
+			expected: "[WARNING] - 4 shards on 3 nodes, 1 unassigned \n \\_[WARNING] node1: 1 shards (0% of cluster.max_shards_per_node 1000), 1.0 shards per GB heap\n \\_[WARNING] node2: 2 shards (0% of cluster.max_shards_per_node 1000), 1.0 shards per GB heap\n \\_[OK] node3: 1 shards (0% of cluster.max_shards_per_node 1000)|nodes.node1.shards=1 nodes.node1.shards_usage=0.1%;80;90 nodes.node1.shards_per_heap_gb=1;0.5 nodes.node2.shards=2 nodes.node2.shards_usage=0.2%;80;90 nodes.node2.shards_per_heap_gb=1;0.5 nodes.node3.shards=1 nodes.node3.shards_usage=0.1%;80;90 shards=4 shards.unassigned=1 shards.oversized=0 shards.largest=64424509440B\nexit status 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newShardsServer(test.settings)
+			defer server.Close()
+
+			cmd := exec.Command("go", append(test.args, "--hostname", server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if actual != test.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+		})
+	}
+}
@@ -1,13 +1,28 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"math"
+	"time"
 
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
 	"github.com/NETWAYS/go-check"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// SnapshotConfig stores the CLI parameters.
+type SnapshotConfig struct {
+	Repository       string
+	Snapshot         string
+	Number           int
+	All              bool
+	NoSnapshotsState string
+}
+
+var cliSnapshotConfig SnapshotConfig
+
 var snapshotCmd = &cobra.Command{
 	Use:   "snapshot",
 	Short: "Checks the status of Elasticsearch snapshots",
@@ -31,108 +46,8 @@ $ check_elasticsearch snapshot --all
 $ check_elasticsearch snapshot --number 5
 [WARNING] - At least one evaluated snapshot is in state PARTIAL
 `,
-	Run: func(cmd *cobra.Command, _ []string) {
-		repository, _ := cmd.Flags().GetString("repository")
-		snapshot, _ := cmd.Flags().GetString("snapshot")
-		numberOfSnapshots, _ := cmd.Flags().GetInt("number")
-		evalAllSnapshots, _ := cmd.Flags().GetBool("all")
-		noSnapshotsState, _ := cmd.Flags().GetString("no-snapshots-state")
-
-		// Convert --no-snapshots-state to integer and validate input
-		noSnapshotsStateInt, err := check.NewStatusFromString(noSnapshotsState)
-		if err != nil {
-			check.ExitError(fmt.Errorf("invalid value for --no-snapshots-state: %s", noSnapshotsState))
-		}
-
-		var (
-			rc     check.Status
-			output string
-		)
-
-		client := cliConfig.NewClient()
-
-		snapResponse, err := client.Snapshot(repository, snapshot)
-		if err != nil {
-			check.ExitError(err)
-		}
-
-		// If all snapshots are to be evaluated
-		if evalAllSnapshots {
-			numberOfSnapshots = len(snapResponse.Snapshots)
-		}
-
-		// If more snapshots are requested than available
-		if numberOfSnapshots > len(snapResponse.Snapshots) {
-			numberOfSnapshots = len(snapResponse.Snapshots)
-		}
-
-		// Evaluate snapshots given their states
-		sStates := make([]check.Status, 0, len(snapResponse.Snapshots))
-
-		// Check status for each snapshot
-		var summary strings.Builder
-
-		for _, snap := range snapResponse.Snapshots[:numberOfSnapshots] {
-			summary.WriteString("\n \\_")
-
-			switch snap.State {
-			default:
-				sStates = append(sStates, check.Unknown)
-
-				fmt.Fprintf(&summary, "[UNKNOWN] Snapshot: %s, State %s, Repository: %s", snap.Snapshot, snap.State, snap.Repository)
-			case "SUCCESS":
-				sStates = append(sStates, check.OK)
-
-				fmt.Fprintf(&summary, "[OK] Snapshot: %s, State %s, Repository: %s", snap.Snapshot, snap.State, snap.Repository)
-			case "PARTIAL":
-				sStates = append(sStates, check.Warning)
-
-				fmt.Fprintf(&summary, "[WARNING] Snapshot: %s, State %s, Repository: %s", snap.Snapshot, snap.State, snap.Repository)
-			case "FAILED":
-				sStates = append(sStates, check.Critical)
-
-				fmt.Fprintf(&summary, "[CRITICAL] Snapshot: %s, State %s, Repository: %s", snap.Snapshot, snap.State, snap.Repository)
-			case "IN PROGRESS":
-				sStates = append(sStates, check.Unknown)
-
-				fmt.Fprintf(&summary, "[UNKNOWN] Snapshot: %s, State %s, Repository: %s", snap.Snapshot, snap.State, snap.Repository)
-			}
-		}
-
-		if len(snapResponse.Snapshots) == 0 {
-			//nolint:exhaustive
-			switch noSnapshotsStateInt {
-			case 0:
-				sStates = append(sStates, check.OK)
-			case 1:
-				sStates = append(sStates, check.Warning)
-			case 2:
-				sStates = append(sStates, check.Critical)
-			case 3:
-				sStates = append(sStates, check.Unknown)
-			}
-		}
-
-		rc = check.WorstState(sStates...)
-
-		if len(snapResponse.Snapshots) == 0 {
-			output = "No snapshots found."
-		} else {
-			switch rc {
-			case check.OK:
-				output = "All evaluated snapshots are in state SUCCESS."
-			case check.Warning:
-				output = "At least one evaluated snapshot is in state PARTIAL."
-			case check.Critical:
-				output = "At least one evaluated snapshot is in state FAILED."
-			case check.Unknown:
-				output = "At least one evaluated snapshot is in state IN_PROGRESS."
-			default:
-				output = "Could not evaluate status of snapshots"
-			}
-		}
-
-		check.Exit(rc, output, "repository:", repository, "snapshot:", snapshot, summary.String())
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliSnapshotConfig)
 	},
 }
 
@@ -140,16 +55,144 @@ func init() {
 	rootCmd.AddCommand(snapshotCmd)
 
 	fs := snapshotCmd.Flags()
+	cliSnapshotConfig.addFlags(fs)
 
-	fs.StringP("snapshot", "s", "*",
+	snapshotCmd.MarkFlagsMutuallyExclusive("number", "all")
+}
+
+func (sc *SnapshotConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&sc.Snapshot, "snapshot", "s", "*",
 		"Comma-separated list of snapshot names to retrieve. Wildcard (*) expressions are supported")
-	fs.StringP("repository", "r", "*",
+	fs.StringVarP(&sc.Repository, "repository", "r", "*",
 		"Comma-separated list of snapshot repository names used to limit the request")
 
-	fs.IntP("number", "N", 1, "Check latest N number snapshots. If not set only the latest snapshot is checked")
-	fs.BoolP("all", "a", false, "Check all retrieved snapshots. If not set only the latest snapshot is checked")
+	fs.IntVarP(&sc.Number, "number", "N", 1, "Check latest N number snapshots. If not set only the latest snapshot is checked")
+	fs.BoolVarP(&sc.All, "all", "a", false, "Check all retrieved snapshots. If not set only the latest snapshot is checked")
 
-	fs.StringP("no-snapshots-state", "T", "UNKNOWN", "State to assign when no snapshots are found (0, 1, 2, 3, OK, WARNING, CRITICAL, UNKNOWN). If not set this defaults to UNKNOWN")
+	fs.StringVarP(&sc.NoSnapshotsState, "no-snapshots-state", "T", "UNKNOWN", "State to assign when no snapshots are found (0, 1, 2, 3, OK, WARNING, CRITICAL, UNKNOWN). If not set this defaults to UNKNOWN")
+}
 
-	snapshotCmd.MarkFlagsMutuallyExclusive("number", "all")
+func (sc *SnapshotConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	numberOfSnapshots := sc.Number
+
+	// Convert --no-snapshots-state to integer and validate input
+	noSnapshotsStateInt, err := check.NewStatusFromString(sc.NoSnapshotsState)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for --no-snapshots-state: %s", sc.NoSnapshotsState)
+	}
+
+	var (
+		rc     check.Status
+		output string
+	)
+
+	snapResponse, err := c.Snapshot(ctx, sc.Repository, sc.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	// If all snapshots are to be evaluated
+	if sc.All {
+		numberOfSnapshots = len(snapResponse.Snapshots)
+	}
+
+	// If more snapshots are requested than available
+	if numberOfSnapshots > len(snapResponse.Snapshots) {
+		numberOfSnapshots = len(snapResponse.Snapshots)
+	}
+
+	// Evaluate snapshots given their states
+	sStates := make([]check.Status, 0, len(snapResponse.Snapshots))
+
+	// Check status for each snapshot
+	var (
+		items  []Item
+		counts = map[string]int{"success": 0, "partial": 0, "failed": 0, "in_progress": 0}
+		// Start time of the newest evaluated snapshot
+		latestStart int
+	)
+
+	for _, snap := range snapResponse.Snapshots[:numberOfSnapshots] {
+		var state check.Status
+
+		switch snap.State {
+		default:
+			state = check.Unknown
+		case "SUCCESS":
+			state = check.OK
+			counts["success"]++
+		case "PARTIAL":
+			state = check.Warning
+			counts["partial"]++
+		case "FAILED":
+			state = check.Critical
+			counts["failed"]++
+		case "IN PROGRESS":
+			state = check.Unknown
+			counts["in_progress"]++
+		}
+
+		sStates = append(sStates, state)
+		latestStart = max(latestStart, snap.StartTimeInMillis)
+
+		items = append(items, Item{
+			Status: state,
+			Output: fmt.Sprintf("Snapshot: %s, State %s, Repository: %s", snap.Snapshot, snap.State, snap.Repository),
+		})
+	}
+
+	if len(snapResponse.Snapshots) == 0 {
+		//nolint:exhaustive
+		switch noSnapshotsStateInt {
+		case 0:
+			sStates = append(sStates, check.OK)
+		case 1:
+			sStates = append(sStates, check.Warning)
+		case 2:
+			sStates = append(sStates, check.Critical)
+		case 3:
+			sStates = append(sStates, check.Unknown)
+		}
+	}
+
+	rc = check.WorstState(sStates...)
+
+	if len(snapResponse.Snapshots) == 0 {
+		output = "No snapshots found."
+	} else {
+		switch rc {
+		case check.OK:
+			output = "All evaluated snapshots are in state SUCCESS."
+		case check.Warning:
+			output = "At least one evaluated snapshot is in state PARTIAL."
+		case check.Critical:
+			output = "At least one evaluated snapshot is in state FAILED."
+		case check.Unknown:
+			output = "At least one evaluated snapshot is in state IN_PROGRESS."
+		default:
+			output = "Could not evaluate status of snapshots"
+		}
+	}
+
+	p := check.PerfdataList{
+		{Label: "snapshots", Value: len(items)},
+		{Label: "snapshots.success", Value: counts["success"]},
+		{Label: "snapshots.partial", Value: counts["partial"]},
+		{Label: "snapshots.failed", Value: counts["failed"]},
+		{Label: "snapshots.in_progress", Value: counts["in_progress"]},
+	}
+
+	if latestStart > 0 {
+		p.Add(&check.Perfdata{
+			Label: "latest_snapshot_age",
+			Value: math.Round(time.Since(time.UnixMilli(int64(latestStart))).Seconds()),
+			Uom:   "s"})
+	}
+
+	return &Result{
+		Status:   rc,
+		Summary:  output + " repository: " + sc.Repository + " snapshot: " + sc.Snapshot,
+		Items:    items,
+		Perfdata: p,
+	}, nil
 }
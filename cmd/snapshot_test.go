@@ -14,7 +14,7 @@ func TestSnapshot_ConnectionRefused(t *testing.T) {
 	out, _ := cmd.CombinedOutput()
 
 	actual := string(out)
-	expected := "[UNKNOWN] - could not fetch snapshots: no node reachable (*errors.errorString)"
+	expected := "[UNKNOWN] - could not fetch snapshots: no node answered successfully: http://localhost:9999: "
 
 	if !strings.Contains(actual, expected) {
 		t.Error("\nActual: ", actual, "\nExpected: ", expected)
@@ -147,6 +147,25 @@ func TestSnapshotCmd(t *testing.T) {
 			args:     []string{"run", "../main.go", "snapshot", "--number", "4"},
 			expected: "[WARNING] - At least one evaluated snapshot is in state PARTIAL",
 		},
+		{
+			name: "snapshot-opensearch",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// OpenSearch sends no X-Elastic-Product header and does not support ordering
+				switch {
+				case r.URL.Path == "/":
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"name":"node-1","cluster_name":"opensearch","version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+				case r.URL.Query().Has("order"):
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":{"root_cause":[{"type":"illegal_argument_exception","reason":"request [/_snapshot/*/*] contains unrecognized parameter: [order]"}]},"status":400}`))
+				default:
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"snapshots":[{"snapshot":"snapshot_1","uuid":"dKb54xw67gvdRctLCxSket","version_id":136327827,"version":"2.11.0","indices":[],"data_streams":[],"include_global_state":true,"state":"FAILED","start_time":"2020-07-06T21:55:18.129Z","start_time_in_millis":1593093628850,"end_time":"2020-07-06T21:55:18.129Z","end_time_in_millis":1593094752018,"duration_in_millis":0,"failures":[],"shards":{"total":0,"failed":0,"successful":0}},{"snapshot":"snapshot_2","uuid":"dKb54xw67gvdRctLCxSket","version_id":136327827,"version":"2.11.0","indices":[],"data_streams":[],"include_global_state":true,"state":"SUCCESS","start_time":"2020-07-06T21:55:18.129Z","start_time_in_millis":1593093628851,"end_time":"2020-07-06T21:55:18.129Z","end_time_in_millis":1593094752018,"duration_in_millis":0,"failures":[],"shards":{"total":0,"failed":0,"successful":0}}]}`))
+				}
+			})),
+			args:     []string{"run", "../main.go", "snapshot"},
+			expected: "[OK] - All evaluated snapshots are in state SUCCESS",
+		},
 	}
 
 	for _, test := range tests {
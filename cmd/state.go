@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// loadState reads the state file of a check into state, the state is
+// unchanged when no file is given or it does not exist yet
+func loadState(path string, state any) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read state file: %w", err)
+	}
+
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return fmt.Errorf("could not parse state file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// saveState writes the state file, via a temporary file so
+// that concurrent runs do not read a partially written file
+func saveState(path string, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("could not encode state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/go-check"
+)
+
+// parseOptionalThreshold parses a threshold, an empty threshold is not set (nil).
+// With size the sizes of the threshold are converted to bytes, e.g. 50GB.
+func parseOptionalThreshold(value string, size bool) (*check.Threshold, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if size {
+		value = parseSizes(value)
+	}
+
+	return check.ParseThreshold(value)
+}
+
+// thresholdState returns the state of the value for the optional thresholds
+func thresholdState(value float64, warn, crit *check.Threshold) check.Status {
+	if crit != nil && crit.DoesViolate(value) {
+		return check.Critical
+	}
+
+	if warn != nil && warn.DoesViolate(value) {
+		return check.Warning
+	}
+
+	return check.OK
+}
+
+var sizeRegexp = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(pb|tb|gb|mb|kb|b)`)
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+// parseSizes replaces the sizes with units in a threshold by bytes, e.g. 1KB:2KB becomes 1024:2048
+func parseSizes(threshold string) string {
+	return sizeRegexp.ReplaceAllStringFunc(threshold, func(s string) string {
+		m := sizeRegexp.FindStringSubmatch(s)
+
+		value, _ := strconv.ParseFloat(m[1], 64)
+
+		for i, unit := range sizeUnits {
+			if unit == strings.ToUpper(m[2]) {
+				value *= math.Pow(1024, float64(i))
+			}
+		}
+
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	})
+}
+
+// formatBytes returns the size with the largest unit, e.g. 1.5GB
+func formatBytes(value float64) string {
+	i := 0
+
+	for math.Abs(value) >= 1024 && i < len(sizeUnits)-1 {
+		value /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%.0fB", value)
+	}
+
+	return fmt.Sprintf("%.1f%s", value, sizeUnits[i])
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NETWAYS/check_elasticsearch/internal/client"
+	es "github.com/NETWAYS/check_elasticsearch/internal/elasticsearch"
+	"github.com/NETWAYS/go-check"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// VersionConfig stores the CLI parameters.
+type VersionConfig struct {
+	MinimumVersion string
+	UpgradeWindow  time.Duration
+	StateFile      string
+}
+
+// versionState is the state file with the start of an upgrade
+type versionState struct {
+	// MixedSince is the time in seconds since the epoch at which the nodes
+	// first ran mixed versions, 0 when all nodes run the same version
+	MixedSince int64 `json:"mixed_since"`
+}
+
+var cliVersionConfig VersionConfig
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Checks the version consistency of the Elasticsearch nodes",
+	Long: `Checks the version consistency of the Elasticsearch nodes
+
+The plugin alerts when:
+	nodes run mixed versions for longer than the upgrade window = WARNING
+	a node runs a version below the minimum version = CRITICAL
+
+The start of an upgrade is determined by the earliest start time of the
+nodes that run the newest version. A restart of all these nodes resets the
+start, e.g. a rolling restart during the upgrade. With --state-file the start
+of an upgrade is stored, so that restarts do not reset it.`,
+	Example: `
+$ check_elasticsearch version --minimum-version 8.11.0
+[OK] - All 3 nodes run version 8.11.1
+
+$ check_elasticsearch version --upgrade-window 48h --state-file /var/lib/check_elasticsearch/version.json
+[WARNING] - Nodes run 2 different versions for 264h0m0s
+ \_ 8.10.4: node-3
+ \_ 8.11.1: node-1, node-2
+`,
+	Run: func(_ *cobra.Command, _ []string) {
+		runCheck(&cliVersionConfig)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	fs := versionCmd.Flags()
+	cliVersionConfig.addFlags(fs)
+	fs.SortFlags = false
+}
+
+func (vc *VersionConfig) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&vc.MinimumVersion, "minimum-version", "",
+		"Minimum version all nodes must run (e.g. 8.11.0)")
+	fs.DurationVar(&vc.UpgradeWindow, "upgrade-window", 24*time.Hour,
+		"Duration nodes may run mixed versions during an upgrade (e.g. 12h)")
+	fs.StringVar(&vc.StateFile, "state-file", "",
+		"File to store the start of an upgrade, so that restarts of the nodes do not reset it")
+}
+
+func (vc *VersionConfig) run(ctx context.Context, c *client.Client) (*Result, error) {
+	var minimum es.Version
+
+	if vc.MinimumVersion != "" {
+		v, err := es.ParseVersion(vc.MinimumVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for --minimum-version: %w", err)
+		}
+
+		minimum = v
+	}
+
+	state := &versionState{}
+
+	err := loadState(vc.StateFile, state)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.NodesInfo(ctx, "jvm")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.Nodes) == 0 {
+		return &Result{Status: check.Unknown, Summary: "No nodes found"}, nil
+	}
+
+	// Group the node names by their version
+	nodesByVersion := make(map[es.Version][]string)
+	// Start time of the nodes per version, used to determine the start of an upgrade
+	startByVersion := make(map[es.Version]int64)
+
+	for _, node := range info.Nodes {
+		v, errParse := es.ParseVersion(node.Version)
+		if errParse != nil {
+			return nil, errParse
+		}
+
+		nodesByVersion[v] = append(nodesByVersion[v], node.Name)
+
+		if start, ok := startByVersion[v]; !ok || node.JVM.StartTimeInMillis < start {
+			startByVersion[v] = node.JVM.StartTimeInMillis
+		}
+	}
+
+	versions := make([]es.Version, 0, len(nodesByVersion))
+	for v := range nodesByVersion {
+		versions = append(versions, v)
+	}
+
+	slices.SortFunc(versions, es.Version.Compare)
+
+	lowest := versions[0]
+	newest := versions[len(versions)-1]
+
+	var (
+		output string
+		states []check.Status
+	)
+
+	if len(versions) == 1 {
+		state.MixedSince = 0
+
+		states = append(states, check.OK)
+		output = fmt.Sprintf("All %d nodes run version %s", len(info.Nodes), newest)
+	} else {
+		start := time.UnixMilli(startByVersion[newest])
+
+		// The stored start is kept when the upgraded nodes were restarted since
+		if state.MixedSince > 0 && time.Unix(state.MixedSince, 0).Before(start) {
+			start = time.Unix(state.MixedSince, 0)
+		}
+
+		state.MixedSince = start.Unix()
+
+		mixedSince := time.Since(start).Round(time.Minute)
+
+		if mixedSince > vc.UpgradeWindow {
+			states = append(states, check.Warning)
+			output = fmt.Sprintf("Nodes run %d different versions for %s", len(versions), mixedSince)
+		} else {
+			states = append(states, check.OK)
+			output = fmt.Sprintf("Nodes run %d different versions, upgrade in progress since %s", len(versions), mixedSince)
+		}
+	}
+
+	if vc.MinimumVersion != "" && lowest.Compare(minimum) < 0 {
+		states = append(states, check.Critical)
+		output += fmt.Sprintf(", version %s is below the minimum version %s", lowest, minimum)
+	}
+
+	if vc.StateFile != "" {
+		err = saveState(vc.StateFile, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]Item, 0, len(versions))
+
+	for _, v := range versions {
+		names := nodesByVersion[v]
+		slices.Sort(names)
+
+		items = append(items, Item{Informational: true, Output: fmt.Sprintf("%s: %s", v, strings.Join(names, ", "))})
+	}
+
+	return &Result{
+		Status:  check.WorstState(states...),
+		Summary: output,
+		Items:   items,
+		Perfdata: check.PerfdataList{
+			{Label: "versions", Value: len(versions)},
+			{Label: "nodes", Value: len(info.Nodes)},
+		},
+	}, nil
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type VersionTest struct {
+	name     string
+	server   *httptest.Server
+	args     []string
+	expected string
+}
+
+func TestVersionCmd(t *testing.T) {
+	tests := []VersionTest{
+		{
+			name: "version-consistent",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"_nodes":{"total":2,"successful":2,"failed":0},"cluster_name":"test","nodes":{"a":{"name":"node-1","version":"8.11.1","jvm":{"start_time_in_millis":1593093628850}},"b":{"name":"node-2","version":"8.11.1","jvm":{"start_time_in_millis":1593093628850}}}}`))
+			})),
+			args:     []string{"run", "../main.go", "version"},
+			expected: "[OK] - All 2 nodes run version 8.11.1 \n \\_ 8.11.1: node-1, node-2|versions=1 nodes=2\n",
+		},
+		{
+			name: "version-mixed-outside-window",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"_nodes":{"total":2,"successful":2,"failed":0},"cluster_name":"test","nodes":{"a":{"name":"node-1","version":"8.11.1","jvm":{"start_time_in_millis":1593093628850}},"b":{"name":"node-2","version":"8.10.4","jvm":{"start_time_in_millis":1593093628850}}}}`))
+			})),
+			args:     []string{"run", "../main.go", "version"},
+			expected: "[WARNING] - Nodes run 2 different versions for ",
+		},
+		{
+			name: "version-mixed-within-window",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"_nodes":{"total":2,"successful":2,"failed":0},"cluster_name":"test","nodes":{"a":{"name":"node-1","version":"8.11.1","jvm":{"start_time_in_millis":1593093628850}},"b":{"name":"node-2","version":"8.10.4","jvm":{"start_time_in_millis":1593093628850}}}}`))
+			})),
+			args:     []string{"run", "../main.go", "version", "--upgrade-window", "1000000h"},
+			expected: "[OK] - Nodes run 2 different versions, upgrade in progress since ",
+		},
+		{
+			name: "version-below-minimum",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"_nodes":{"total":1,"successful":1,"failed":0},"cluster_name":"test","nodes":{"a":{"name":"node-1","version":"7.17.9","jvm":{"start_time_in_millis":1593093628850}}}}`))
+			})),
+			args:     []string{"run", "../main.go", "version", "--minimum-version", "8.0"},
+			expected: "[CRITICAL] - All 1 nodes run version 7.17.9, version 7.17.9 is below the minimum version 8.0.0 \n \\_ 7.17.9: node-1|versions=1 nodes=1\n",
+		},
+		{
+			name: "version-invalid-minimum",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				w.WriteHeader(http.StatusOK)
+			})),
+			args:     []string{"run", "../main.go", "version", "--minimum-version", "eight"},
+			expected: "[UNKNOWN] - invalid value for --minimum-version: invalid version number: 'eight'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			cmd := exec.Command("go", append(test.args, "--hostname", test.server.URL)...)
+			out, _ := cmd.CombinedOutput()
+
+			actual := string(out)
+
+			if !strings.Contains(actual, test.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", test.expected)
+			}
+
+		})
+	}
+}
+
+func TestVersion_StateFile(t *testing.T) {
+	// The upgraded node was restarted a minute ago
+	started := time.Now().Add(-time.Minute).UnixMilli()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"_nodes":{"total":2,"successful":2,"failed":0},"cluster_name":"test","nodes":{"a":{"name":"node-1","version":"8.11.1","jvm":{"start_time_in_millis":%d}},"b":{"name":"node-2","version":"8.10.4","jvm":{"start_time_in_millis":1593093628850}}}}`, started)
+	}))
+	defer server.Close()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	// The upgrade started two days ago
+	mixedSince := time.Now().Add(-48 * time.Hour).Unix()
+
+	err := os.WriteFile(stateFile, fmt.Appendf(nil, `{"mixed_since":%d}`, mixedSince), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", "../main.go", "version", "--hostname", server.URL, "--state-file", stateFile)
+	out, _ := cmd.CombinedOutput()
+
+	actual := string(out)
+	expected := "[WARNING] - Nodes run 2 different versions for 48h0m0s"
+
+	if !strings.HasPrefix(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	state, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(state) != fmt.Sprintf(`{"mixed_since":%d}`, mixedSince) {
+		t.Error("state file not kept: ", string(state))
+	}
+}
@@ -2,20 +2,118 @@ package client
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	es "github.com/NETWAYS/check_elasticsearch/internal/elasticsearch"
 )
 
+// ErrNotSupported is returned when the Elasticsearch version does not provide
+// the requested API
+var ErrNotSupported = errors.New("API not supported by this Elasticsearch version")
+
+// ErrNotSupportedOpenSearch is returned when an API is not provided by OpenSearch
+var ErrNotSupportedOpenSearch = errors.New("API not supported by OpenSearch")
+
+// ErrUnknownProduct is returned when the server is not Elasticsearch,
+// e.g. a login page of a reverse proxy
+var ErrUnknownProduct = errors.New("the server is not Elasticsearch")
+
+// productHeaderVersion is the first version that sends the X-Elastic-Product header
+var productHeaderVersion = es.Version{Major: 7, Minor: 14}
+
+// DefaultFailoverStatus are the HTTP status codes that indicate a temporary
+// problem of a node, the request is tried on the next node
+var DefaultFailoverStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// NodeError describes why a request to a node failed
+type NodeError struct {
+	URL string
+	Err error
+}
+
+func (e *NodeError) Error() string {
+	return e.URL + ": " + e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// NodesError is returned when no node answered a request successfully, it
+// contains the last error of each node, e.g. a connection error or one of the FailoverStatus
+type NodesError []*NodeError
+
+func (e NodesError) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, ne := range e {
+		messages = append(messages, ne.Error())
+	}
+
+	return "no node answered successfully: " + strings.Join(messages, ", ")
+}
+
 type Client struct {
 	Client http.Client
 	URLs   []*url.URL
+	// Retries is the number of additional attempts on all nodes
+	// when no node answered a request
+	Retries int
+	// RetryBackoff is the time to wait before the first retry,
+	// it is doubled for every further retry
+	RetryBackoff time.Duration
+	// FailoverStatus are the HTTP status codes on which the
+	// request is tried on the next node
+	FailoverStatus []int
+	// Sniff discovers the other nodes of the cluster after the first
+	// successful request and uses them as fallbacks
+	Sniff bool
+	// SniffRoles limits the discovered nodes to nodes with one of these
+	// roles, by default all nodes except master-only nodes are used
+	SniffRoles []string
+	// SkipProductCheck disables the verification that the server is Elasticsearch
+	SkipProductCheck bool
+	// TLSConfig is the TLS configuration of the transport, it is used to
+	// connect to the nodes for the certificates they present
+	TLSConfig *tls.Config
+
+	// The Client can be used concurrently, mu guards the following fields
+	mu      sync.Mutex
+	sniffed bool
+	// sniffedURLs are the discovered nodes, used after the URLs
+	sniffedURLs []*url.URL
+	// isElasticsearch is set when the X-Elastic-Product header was sent
+	isElasticsearch bool
+	// info is the answer of the root endpoint, when it was requested
+	info *es.InfoResponse
+	// failedNodes contains the errors of nodes that failed
+	// before another node answered a request
+	failedNodes []*NodeError
+
+	// productMu lets concurrent requests wait for the product check
+	productMu      sync.Mutex
+	productChecked bool
+	productErr     error
 }
 
 func NewClient(urls []*url.URL, rt http.RoundTripper) *Client {
@@ -24,39 +122,463 @@ func NewClient(urls []*url.URL, rt http.RoundTripper) *Client {
 	}
 
 	return &Client{
-		URLs:   urls,
-		Client: *c,
+		URLs:           urls,
+		Client:         *c,
+		FailoverStatus: DefaultFailoverStatus,
+	}
+}
+
+// FailedNodes returns the errors of nodes that failed before
+// another node answered a request
+func (c *Client) FailedNodes() []*NodeError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.failedNodes)
+}
+
+// nodes returns the URLs of the nodes, including the sniffed nodes
+func (c *Client) nodes() []*url.URL {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Concat(c.URLs, c.sniffedURLs)
+}
+
+// ForURL returns a Client for the given node with the same configuration and
+// transport, e.g. for a node of another cluster. Its nodes are not sniffed.
+func (c *Client) ForURL(u *url.URL) *Client {
+	nc := NewClient([]*url.URL{u}, c.Client.Transport)
+	nc.Client.Timeout = c.Client.Timeout
+	nc.Retries = c.Retries
+	nc.RetryBackoff = c.RetryBackoff
+	nc.FailoverStatus = c.FailoverStatus
+	nc.SkipProductCheck = c.SkipProductCheck
+	nc.TLSConfig = c.TLSConfig
+
+	return nc
+}
+
+// newNodeError returns a NodeError for the given node, removing
+// the redundant request details from the error
+func newNodeError(hostURL *url.URL, err error) *NodeError {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
 	}
+
+	return &NodeError{URL: hostURL.Redacted(), Err: err}
 }
 
 // Perform wraps the Client's HTTP call so that we can try all given
-// nodes in case one node is not reachable
+// nodes in case one node is not reachable. Connection errors and
+// the HTTP status codes in FailoverStatus are retried with backoff.
+// The request is copied for each node, its URL is relative to the
+// node's URL and its body is rewound via GetBody.
 func (c *Client) Perform(req *http.Request) (*http.Response, error) {
-	originalPath := req.URL.Path
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return &http.Response{}, errors.New("request body can not be rewound for failover")
+	}
+
+	urls := c.nodes()
+
+	// Last error of each node
+	nodeErrors := make([]*NodeError, len(urls))
+	backoff := c.RetryBackoff
+
+	for attempt := range max(c.Retries, 0) + 1 {
+		if attempt > 0 {
+			select {
+			case <-req.Context().Done():
+				return &http.Response{}, fmt.Errorf("timeout exceeded: %w", collectNodeErrors(nodeErrors))
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+		}
+
+		for i, hostURL := range urls {
+			nodeReq, err := newNodeRequest(req, hostURL)
+			if err != nil {
+				return &http.Response{}, err
+			}
+
+			resp, errDo := c.Client.Do(nodeReq) //nolint: gosec
+			if errDo != nil {
+				nodeErrors[i] = newNodeError(hostURL, errDo)
+
+				// No time is left to try the other nodes
+				if req.Context().Err() != nil {
+					return &http.Response{}, fmt.Errorf("timeout exceeded: %w", collectNodeErrors(nodeErrors))
+				}
+
+				// If there's an error we try the next host
+				continue
+			}
+
+			if slices.Contains(c.FailoverStatus, resp.StatusCode) {
+				nodeErrors[i] = &NodeError{URL: hostURL.Redacted(), Err: errors.New(responseStatus(resp))}
+
+				// Drain the body so that the connection can be reused
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				continue
+			}
+
+			// Remember the nodes that failed before this one answered
+			nodeErrors[i] = nil
+
+			c.mu.Lock()
+			c.failedNodes = append(c.failedNodes, collectNodeErrors(nodeErrors)...)
+			sniff := c.Sniff && !c.sniffed
+			c.sniffed = c.sniffed || sniff
+			c.mu.Unlock()
+
+			if !c.SkipProductCheck {
+				errCheck := c.verifyProduct(req.Context(), hostURL, resp)
+				if errCheck != nil {
+					resp.Body.Close()
+
+					return &http.Response{}, errCheck
+				}
+			}
+
+			if sniff {
+				c.sniffNodes(req.Context(), hostURL)
+			}
+
+			return resp, nil
+		}
+	}
+
+	return &http.Response{}, collectNodeErrors(nodeErrors)
+}
+
+// newNodeRequest returns a copy of the request for the given node.
+// The path is appended to the path of the node's URL and the query
+// parameters of both are kept.
+func newNodeRequest(req *http.Request, hostURL *url.URL) (*http.Request, error) {
+	nodeReq := req.Clone(req.Context())
+
+	nodeReq.URL = hostURL.JoinPath(req.URL.Path)
+	// JoinPath omits the leading slash when the node's URL has no path
+	if !strings.HasPrefix(nodeReq.URL.Path, "/") {
+		nodeReq.URL.Path = "/" + nodeReq.URL.Path
+	}
+
+	nodeReq.URL.RawQuery = joinQuery(hostURL.RawQuery, req.URL.RawQuery)
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("could not rewind request body: %w", err)
+		}
+
+		nodeReq.Body = body
+	}
+
+	return nodeReq, nil
+}
+
+// joinQuery joins the query strings of the node's URL and the request
+func joinQuery(nodeQuery, reqQuery string) string {
+	if nodeQuery == "" {
+		return reqQuery
+	}
+
+	if reqQuery == "" {
+		return nodeQuery
+	}
+
+	return nodeQuery + "&" + reqQuery
+}
+
+// responseStatus returns the status of a failed response, including the
+// root causes reported by Elasticsearch in the response body
+func responseStatus(resp *http.Response) string {
+	var r es.ErrorResponse
+
+	// The body might not be an Elasticsearch error, e.g. from a proxy
+	_ = json.NewDecoder(resp.Body).Decode(&r)
+
+	if reasons := r.GetErrors(); reasons != "" {
+		return resp.Status + ": " + reasons
+	}
+
+	return resp.Status
+}
+
+// verifyProduct runs the product check until it has a verdict, concurrent
+// requests wait for its result. Connection errors and server errors of the
+// root endpoint are no verdict, the next request checks again.
+func (c *Client) verifyProduct(ctx context.Context, hostURL *url.URL, resp *http.Response) error {
+	c.productMu.Lock()
+	defer c.productMu.Unlock()
+
+	if c.productChecked {
+		return c.productErr
+	}
+
+	err := c.checkProduct(ctx, hostURL, resp)
+	if err == nil || errors.Is(err, ErrUnknownProduct) {
+		c.productChecked = true
+		c.productErr = err
+	}
+
+	return err
+}
+
+// checkProduct verifies that the server that answered is Elasticsearch.
+// Since 7.14 Elasticsearch sends the X-Elastic-Product header, older
+// versions are identified by the tagline of the root endpoint.
+func (c *Client) checkProduct(ctx context.Context, hostURL *url.URL, resp *http.Response) error {
+	if resp.Header.Get("X-Elastic-Product") == "Elasticsearch" {
+		c.mu.Lock()
+		c.isElasticsearch = true
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	// The root endpoint is requested from the node that answered
+	req, err = newNodeRequest(req, hostURL)
+	if err != nil {
+		return err
+	}
+
+	rootResp, err := c.Client.Do(req) //nolint: gosec
+	if err != nil {
+		return fmt.Errorf("could not fetch root endpoint: %w", newNodeError(hostURL, err))
+	}
+
+	defer rootResp.Body.Close()
+
+	switch rootResp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// Elasticsearch before 7.14 requires authentication for the root endpoint,
+		// the authentication error is reported by the original request
+		return nil
+	default:
+		if rootResp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("could not fetch root endpoint: %s", rootResp.Status)
+		}
+
+		return fmt.Errorf("%w: root endpoint answered with %s", ErrUnknownProduct, rootResp.Status)
+	}
+
+	var info es.InfoResponse
+
+	err = json.NewDecoder(rootResp.Body).Decode(&info)
+	if err != nil {
+		return fmt.Errorf("%w: unexpected answer of the root endpoint", ErrUnknownProduct)
+	}
+
+	// OpenSearch does not send the X-Elastic-Product header
+	if info.IsOpenSearch() {
+		c.setInfo(&info)
+		return nil
+	}
+
+	if info.Tagline != "You Know, for Search" {
+		return fmt.Errorf("%w: unexpected answer of the root endpoint", ErrUnknownProduct)
+	}
+
+	c.setInfo(&info)
+
+	v, err := es.ParseVersion(info.Version.Number)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownProduct, err.Error())
+	}
+
+	if v.Compare(productHeaderVersion) >= 0 {
+		return fmt.Errorf("%w: missing X-Elastic-Product header for version %s", ErrUnknownProduct, v)
+	}
+
+	return nil
+}
+
+// sniffNodes adds the HTTP publish addresses of the cluster's nodes to the
+// URLs. The scheme, credentials and path of the node that answered are used.
+// Sniffing is optional, when it fails the given URLs are used as before.
+func (c *Client) sniffNodes(ctx context.Context, hostURL *url.URL) {
+	info, err := c.NodesInfo(ctx, "http")
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, u := range sniffedURLs(hostURL, info, c.SniffRoles) {
+		known := slices.ContainsFunc(slices.Concat(c.URLs, c.sniffedURLs), func(known *url.URL) bool {
+			return known.Host == u.Host
+		})
+
+		if !known {
+			c.sniffedURLs = append(c.sniffedURLs, u)
+		}
+	}
+}
+
+// isMasterOnly returns true for nodes that only have the master role,
+// which is called cluster_manager since OpenSearch 2.0
+func isMasterOnly(roles []string) bool {
+	return len(roles) > 0 && !slices.ContainsFunc(roles, func(role string) bool {
+		return role != "master" && role != "cluster_manager"
+	})
+}
+
+// sniffedURLs returns the URLs of the nodes with one of the given roles,
+// or of all nodes except master-only nodes if no roles are given
+func sniffedURLs(hostURL *url.URL, info *es.NodesInfoResponse, roles []string) []*url.URL {
+	// Sort the node IDs so that the order of the fallbacks is stable
+	ids := make([]string, 0, len(info.Nodes))
+	for id := range info.Nodes {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
 
-	for _, hostURL := range c.URLs {
-		// For each URL take the request, prepend the URL
-		u, _ := url.JoinPath(hostURL.String(), originalPath)
+	urls := make([]*url.URL, 0, len(ids))
 
-		req.URL, _ = url.Parse(u)
+	for _, id := range ids {
+		node := info.Nodes[id]
 
-		resp, errDo := c.Client.Do(req) //nolint: gosec
-		if errDo != nil {
-			// If there's an error we try the next host
+		if node.HTTP.PublishAddress == "" {
 			continue
 		}
 
-		return resp, errDo
+		if len(roles) > 0 {
+			if !slices.ContainsFunc(node.Roles, func(role string) bool { return slices.Contains(roles, role) }) {
+				continue
+			}
+		} else if isMasterOnly(node.Roles) {
+			continue
+		}
+
+		u := *hostURL
+		u.Host = publishHost(node.HTTP.PublishAddress)
+
+		urls = append(urls, &u)
 	}
 
-	return &http.Response{}, errors.New("no node reachable")
+	return urls
 }
 
-// Health retrieves the Cluster's health state
-func (c *Client) Health() (*es.HealthResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// publishHost returns the host of a publish address, which is either
+// ip:port or hostname/ip:port when a hostname is configured
+func publishHost(address string) string {
+	hostname, ipPort, found := strings.Cut(address, "/")
+	if !found {
+		return address
+	}
+
+	_, port, err := net.SplitHostPort(ipPort)
+	if err != nil {
+		return ipPort
+	}
+
+	return net.JoinHostPort(hostname, port)
+}
+
+// collectNodeErrors returns the errors of all nodes that failed
+func collectNodeErrors(nodeErrors []*NodeError) NodesError {
+	var errs NodesError
+
+	for _, ne := range nodeErrors {
+		if ne != nil {
+			errs = append(errs, ne)
+		}
+	}
+
+	return errs
+}
+
+// Info retrieves the answer of the root endpoint, which contains the
+// version and distribution of the cluster
+func (c *Client) Info(ctx context.Context) (*es.InfoResponse, error) {
+	if info := c.cachedInfo(); info != nil {
+		return info, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+
+	r := &es.InfoResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch cluster information: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for cluster information: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	c.setInfo(r)
+
+	return r, nil
+}
+
+func (c *Client) cachedInfo() *es.InfoResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.info
+}
+
+func (c *Client) setInfo(info *es.InfoResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.info = info
+}
+
+// knownOpenSearch returns true when the cluster is already known to run OpenSearch
+func (c *Client) knownOpenSearch() bool {
+	info := c.cachedInfo()
+
+	return info != nil && info.IsOpenSearch()
+}
+
+// IsOpenSearch returns true when the cluster runs OpenSearch. The distribution
+// is known from the product check, otherwise the root endpoint is requested.
+func (c *Client) IsOpenSearch(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	isElasticsearch := c.isElasticsearch
+	c.mu.Unlock()
+
+	if isElasticsearch {
+		return false, nil
+	}
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return info.IsOpenSearch(), nil
+}
 
+// Health retrieves the Cluster's health state
+func (c *Client) Health(ctx context.Context) (*es.HealthResponse, error) {
 	u := "/_cluster/health"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
@@ -72,12 +594,49 @@ func (c *Client) Health() (*es.HealthResponse, error) {
 		return r, fmt.Errorf("could not fetch cluster health: %s", err.Error())
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return r, fmt.Errorf("request failed for cluster health: %s", resp.Status)
+		return r, fmt.Errorf("request failed for cluster health: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// HealthReport retrieves the Cluster's health report, available since
+// Elasticsearch 8.7. Returns ErrNotSupported on older versions.
+func (c *Client) HealthReport(ctx context.Context) (*es.HealthReportResponse, error) {
+	u := "/_health_report"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := &es.HealthReportResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch health report: %s", err.Error())
 	}
 
 	defer resp.Body.Close()
 
+	// Older versions answer with 'no handler found for uri'
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed:
+		return r, ErrNotSupported
+	default:
+		return r, fmt.Errorf("request failed for health report: %s", responseStatus(resp))
+	}
+
 	err = json.NewDecoder(resp.Body).Decode(r)
 	if err != nil {
 		return r, fmt.Errorf("error parsing the response body: %w", err)
@@ -88,7 +647,7 @@ func (c *Client) Health() (*es.HealthResponse, error) {
 
 // SearchMessages runs a query_string query and returns the
 // count of documents and the requesed values via messageKey
-func (c *Client) SearchMessages(index string, query string, messageKey string) (uint, []string, error) {
+func (c *Client) SearchMessages(ctx context.Context, index string, query string, messageKey string) (uint, []string, error) {
 	queryBody := es.SearchRequest{
 		Query: es.Query{
 			QueryString: &es.QueryString{
@@ -110,17 +669,13 @@ func (c *Client) SearchMessages(index string, query string, messageKey string) (
 
 	u := index + "/_search"
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, body)
-
-	req.Header.Add("Content-Type", "application/json")
-
 	if err != nil {
 		return total, messages, fmt.Errorf("error creating request: %w", err)
 	}
 
+	req.Header.Add("Content-Type", "application/json")
+
 	p := req.URL.Query()
 	p.Add("track_total_hits", "true")
 	p.Add("size", "1")
@@ -143,6 +698,9 @@ func (c *Client) SearchMessages(index string, query string, messageKey string) (
 
 	if resp.StatusCode != http.StatusOK {
 		queryErrors := response.GetErrors()
+		if queryErrors == "" {
+			queryErrors = resp.Status
+		}
 
 		return total, messages, fmt.Errorf("failed to run query: %s", queryErrors)
 	}
@@ -166,12 +724,10 @@ func (c *Client) SearchMessages(index string, query string, messageKey string) (
 	return total, messages, nil
 }
 
-// NodeStats retrieves the Cluster's node statistics
-func (c *Client) NodeStats() (*es.ClusterStats, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	u := "/_nodes/stats"
+// NodeStats retrieves the Cluster's node statistics, limited to
+// the given metrics (e.g. jvm, ingest)
+func (c *Client) NodeStats(ctx context.Context, metrics ...string) (*es.ClusterStats, error) {
+	u, _ := url.JoinPath("/_nodes/stats", strings.Join(metrics, ","))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -186,12 +742,12 @@ func (c *Client) NodeStats() (*es.ClusterStats, error) {
 		return r, fmt.Errorf("could not fetch cluster nodes statistics: %s", err.Error())
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return r, fmt.Errorf("request failed for cluster nodes statistics: %s", resp.Status)
+		return r, fmt.Errorf("request failed for cluster nodes statistics: %s", responseStatus(resp))
 	}
 
-	defer resp.Body.Close()
-
 	err = json.NewDecoder(resp.Body).Decode(r)
 	if err != nil {
 		return r, fmt.Errorf("error parsing the response body: %w", err)
@@ -200,35 +756,664 @@ func (c *Client) NodeStats() (*es.ClusterStats, error) {
 	return r, nil
 }
 
-// Snapshot retrieves the cluster's snapshot states
-func (c *Client) Snapshot(repository string, snapshot string) (*es.SnapshotResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// CatIndices retrieves the document count and store sizes in bytes of
+// the indices matching the pattern
+func (c *Client) CatIndices(ctx context.Context, pattern string) ([]es.CatIndex, error) {
+	u, _ := url.JoinPath("/_cat/indices", pattern)
 
-	r := &es.SnapshotResponse{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
-	u, _ := url.JoinPath("/_snapshot/", repository, snapshot)
+	var r []es.CatIndex
 
-	// Retrieve snapshots in descending order to get latest
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?order=desc", nil)
 	if err != nil {
 		return r, fmt.Errorf("error creating request: %w", err)
 	}
 
+	p := req.URL.Query()
+	p.Add("format", "json")
+	p.Add("bytes", "b")
+	p.Add("h", "index,health,status,docs.count,store.size,pri.store.size")
+	req.URL.RawQuery = p.Encode()
+
 	resp, err := c.Perform(req)
 	if err != nil {
-		return r, fmt.Errorf("could not fetch snapshots: %s", err.Error())
+		return r, fmt.Errorf("could not fetch indices: %s", err.Error())
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return r, fmt.Errorf("request failed for snapshots: %s", resp.Status)
+		return r, fmt.Errorf("request failed for indices: %s", responseStatus(resp))
 	}
 
-	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// CatShards retrieves the shards of the Cluster with their store size in bytes
+func (c *Client) CatShards(ctx context.Context) ([]es.CatShard, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/_cat/shards", nil)
+
+	var r []es.CatShard
 
-	err = json.NewDecoder(resp.Body).Decode(r)
 	if err != nil {
-		return r, fmt.Errorf("could not decode snapshot response: %w", err)
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	p := req.URL.Query()
+	p.Add("format", "json")
+	p.Add("bytes", "b")
+	p.Add("h", "index,shard,prirep,state,store,node")
+	req.URL.RawQuery = p.Encode()
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch shards: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for shards: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// ClusterSettings retrieves the Cluster's settings including the defaults
+func (c *Client) ClusterSettings(ctx context.Context) (*es.ClusterSettingsResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/_cluster/settings", nil)
+
+	r := &es.ClusterSettingsResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	p := req.URL.Query()
+	p.Add("include_defaults", "true")
+	p.Add("flat_settings", "true")
+	req.URL.RawQuery = p.Encode()
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch cluster settings: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for cluster settings: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// DataStreams retrieves the data streams matching the name, wildcard (*) expressions are supported
+func (c *Client) DataStreams(ctx context.Context, name string) (*es.DataStreamsResponse, error) {
+	u, _ := url.JoinPath("/_data_stream", name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := &es.DataStreamsResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch data streams: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for data streams: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// IndexSettings retrieves the setting of the indices matching the pattern,
+// including its default value
+func (c *Client) IndexSettings(ctx context.Context, pattern string, setting string) (es.IndexSettingsResponse, error) {
+	u, _ := url.JoinPath("/", pattern, "_settings", setting)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := es.IndexSettingsResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	p := req.URL.Query()
+	p.Add("flat_settings", "true")
+	p.Add("include_defaults", "true")
+	req.URL.RawQuery = p.Encode()
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch index settings: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for index settings: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// IndexCreationDates retrieves the creation dates of the indices. The indices are
+// requested in batches, so that the request line stays below the HTTP limit.
+func (c *Client) IndexCreationDates(ctx context.Context, indices []string) (map[string]time.Time, error) {
+	dates := make(map[string]time.Time, len(indices))
+
+	for _, batch := range joinIndices(indices) {
+		r, err := c.IndexSettings(ctx, batch, "index.creation_date")
+		if err != nil {
+			return nil, err
+		}
+
+		for index, settings := range r {
+			value, _ := settings.Setting("index.creation_date")
+
+			millis, errParse := strconv.ParseInt(value, 10, 64)
+			if errParse != nil {
+				return nil, fmt.Errorf("invalid creation date of index %s: %q", index, value)
+			}
+
+			dates[index] = time.UnixMilli(millis)
+		}
+	}
+
+	return dates, nil
+}
+
+// ISMPolicies retrieves the OpenSearch Index State Management policies of the
+// indices, indices that are not managed by ISM are omitted
+func (c *Client) ISMPolicies(ctx context.Context, indices []string) (map[string]string, error) {
+	policies := make(map[string]string, len(indices))
+
+	for _, batch := range joinIndices(indices) {
+		r, err := c.ismExplain(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for index, raw := range r {
+			var explain es.ISMExplain
+
+			if json.Unmarshal(raw, &explain) != nil || explain.PolicyID == "" {
+				continue
+			}
+
+			policies[index] = explain.PolicyID
+		}
+	}
+
+	return policies, nil
+}
+
+// ismExplain retrieves the ISM state of the comma-separated indices. The indices
+// are keys next to the total_managed_indices, so their values are not decoded.
+func (c *Client) ismExplain(ctx context.Context, indices string) (map[string]json.RawMessage, error) {
+	u, _ := url.JoinPath("/_plugins/_ism/explain", indices)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := map[string]json.RawMessage{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch ISM policies: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for ISM policies: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// maxIndicesLength is the maximum length of the comma-separated indices in
+// the path of a request. Elasticsearch rejects request lines longer than 4KB,
+// the rest is left for the path of the API and the query parameters.
+const maxIndicesLength = 3072
+
+// joinIndices returns the indices as comma-separated lists, each list is
+// shorter than maxIndicesLength unless a single index name is longer
+func joinIndices(indices []string) []string {
+	var (
+		batches []string
+		batch   strings.Builder
+	)
+
+	for _, index := range indices {
+		if batch.Len() > 0 && batch.Len()+1+len(index) > maxIndicesLength {
+			batches = append(batches, batch.String())
+			batch.Reset()
+		}
+
+		if batch.Len() > 0 {
+			batch.WriteString(",")
+		}
+
+		batch.WriteString(index)
+	}
+
+	if batch.Len() > 0 {
+		batches = append(batches, batch.String())
+	}
+
+	return batches
+}
+
+// Mappings retrieves the mappings of the indices matching the pattern
+func (c *Client) Mappings(ctx context.Context, pattern string) (es.MappingsResponse, error) {
+	u, _ := url.JoinPath("/", pattern, "_mapping")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := es.MappingsResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch mappings: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for mappings: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// FieldCaps retrieves the capabilities of all fields of the indices matching the pattern
+func (c *Client) FieldCaps(ctx context.Context, pattern string) (*es.FieldCapsResponse, error) {
+	u, _ := url.JoinPath("/", pattern, "_field_caps")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := &es.FieldCapsResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	p := req.URL.Query()
+	p.Add("fields", "*")
+	req.URL.RawQuery = p.Encode()
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch field capabilities: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for field capabilities: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// NodesInfo retrieves the Cluster's node information, limited to
+// the given metrics (e.g. jvm, http)
+func (c *Client) NodesInfo(ctx context.Context, metrics ...string) (*es.NodesInfoResponse, error) {
+	u, _ := url.JoinPath("/_nodes", strings.Join(metrics, ","))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := &es.NodesInfoResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch cluster nodes information: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for cluster nodes information: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// License retrieves the Cluster's license information
+func (c *Client) License(ctx context.Context) (*es.LicenseResponse, error) {
+	u := "/_license"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := &es.LicenseResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch license: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if c.knownOpenSearch() {
+			return r, ErrNotSupportedOpenSearch
+		}
+
+		return r, fmt.Errorf("request failed for license: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// Certificates retrieves the certificates used to encrypt the HTTP
+// and transport layer communication of the Cluster
+func (c *Client) Certificates(ctx context.Context) ([]es.Certificate, error) {
+	u := "/_ssl/certificates"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	var r []es.Certificate
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch certificates: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if c.knownOpenSearch() {
+			return r, ErrNotSupportedOpenSearch
+		}
+
+		return r, fmt.Errorf("request failed for certificates: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// PeerCertificates retrieves the TLS certificates presented by the first
+// node that accepts a TLS connection and the node's address. The certificates
+// are not verified during the handshake, so that expired or untrusted
+// certificates can be evaluated, see VerifyPeerCertificates.
+func (c *Client) PeerCertificates(ctx context.Context) (string, []*x509.Certificate, error) {
+	urls := c.nodes()
+	nodeErrors := make([]*NodeError, len(urls))
+
+	for i, hostURL := range urls {
+		if hostURL.Scheme != "https" {
+			nodeErrors[i] = &NodeError{URL: hostURL.Redacted(), Err: errors.New("connection does not use TLS")}
+			continue
+		}
+
+		config := &tls.Config{} //nolint: gosec
+		if c.TLSConfig != nil {
+			config = c.TLSConfig.Clone()
+		}
+
+		// The certificates are evaluated by the caller
+		config.InsecureSkipVerify = true //nolint: gosec
+
+		addr := hostURL.Host
+		if hostURL.Port() == "" {
+			addr = net.JoinHostPort(hostURL.Hostname(), "443")
+		}
+
+		dialer := &tls.Dialer{Config: config}
+
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			nodeErrors[i] = newNodeError(hostURL, err)
+			continue
+		}
+
+		certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+		conn.Close()
+
+		return hostURL.Host, certificates, nil
+	}
+
+	return "", nil, fmt.Errorf("could not connect to node: %w", collectNodeErrors(nodeErrors))
+}
+
+// VerifyPeerCertificates verifies that the certificates presented by the host are
+// trusted and valid for its name, like the TLS handshake does unless the TLSConfig
+// skips the verification. The expiry of the certificate is not verified.
+func (c *Client) VerifyPeerCertificates(host string, certificates []*x509.Certificate) error {
+	if len(certificates) == 0 {
+		return errors.New("no certificate presented")
+	}
+
+	opts := x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		opts.DNSName = name
+	}
+
+	if c.TLSConfig != nil {
+		if c.TLSConfig.InsecureSkipVerify {
+			return nil
+		}
+
+		opts.Roots = c.TLSConfig.RootCAs
+	}
+
+	for _, cert := range certificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	// An expired certificate is verified at the time it expired
+	leaf := certificates[0]
+	if time.Now().After(leaf.NotAfter) {
+		opts.CurrentTime = leaf.NotAfter
+	}
+
+	_, err := leaf.Verify(opts)
+
+	return err
+}
+
+// APIKeys retrieves the API keys of the Cluster. The keys can be limited to the
+// ones owned by the current user or to a realm and username.
+func (c *Client) APIKeys(ctx context.Context, owner bool, realm string, username string) (*es.APIKeysResponse, error) {
+	u := "/_security/api_key"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	r := &es.APIKeysResponse{}
+
+	if err != nil {
+		return r, fmt.Errorf("error creating request: %w", err)
+	}
+
+	p := req.URL.Query()
+
+	if owner {
+		p.Add("owner", "true")
+	}
+
+	if realm != "" {
+		p.Add("realm_name", realm)
+	}
+
+	if username != "" {
+		p.Add("username", username)
+	}
+
+	req.URL.RawQuery = p.Encode()
+
+	resp, err := c.Perform(req)
+	if err != nil {
+		return r, fmt.Errorf("could not fetch API keys: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if c.knownOpenSearch() {
+			return r, ErrNotSupportedOpenSearch
+		}
+
+		return r, fmt.Errorf("request failed for API keys: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r, nil
+}
+
+// Snapshot retrieves the cluster's snapshot states
+func (c *Client) Snapshot(ctx context.Context, repository string, snapshot string) (*es.SnapshotResponse, error) {
+	r := &es.SnapshotResponse{}
+
+	u, _ := url.JoinPath("/_snapshot/", repository, snapshot)
+
+	fetch := func(ordered bool) (*http.Response, error) {
+		query := ""
+		// Retrieve snapshots in descending order to get latest
+		if ordered {
+			query = "?order=desc"
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		resp, err := c.Perform(req)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch snapshots: %s", err.Error())
+		}
+
+		return resp, nil
+	}
+
+	openSearch := c.knownOpenSearch()
+
+	resp, err := fetch(!openSearch)
+	if err != nil {
+		return r, err
+	}
+
+	// OpenSearch does not support ordering, the snapshots are sorted below
+	if resp.StatusCode == http.StatusBadRequest && !openSearch {
+		openSearch, _ = c.IsOpenSearch(ctx)
+
+		if openSearch {
+			resp.Body.Close()
+
+			resp, err = fetch(false)
+			if err != nil {
+				return r, err
+			}
+		}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return r, fmt.Errorf("request failed for snapshots: %s", responseStatus(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil {
+		return r, fmt.Errorf("could not decode snapshot response: %w", err)
+	}
+
+	if openSearch {
+		slices.SortStableFunc(r.Snapshots, func(a, b es.Snapshot) int {
+			return cmp.Compare(b.StartTimeInMillis, a.StartTimeInMillis)
+		})
 	}
 
 	return r, nil
@@ -3,6 +3,7 @@ package elasticsearch
 import (
 	"slices"
 	"strings"
+	"time"
 )
 
 type HealthResponse struct {
@@ -21,15 +22,56 @@ type HealthResponse struct {
 	NumberOfInFlightFetch       int     `json:"number_of_in_flight_fetch"`
 	TaskMaxWaitingInQueueMillis int     `json:"task_max_waiting_in_queue_millis"`
 	ActiveShardsPercentAsNumber float64 `json:"active_shards_percent_as_number"`
+	// Only reported by OpenSearch, discovered_master is deprecated since 2.0
+	DiscoveredMaster         *bool `json:"discovered_master"`
+	DiscoveredClusterManager *bool `json:"discovered_cluster_manager"`
+}
+
+// ClusterManagerDiscovered returns false when OpenSearch reports that no cluster
+// manager was discovered, Elasticsearch does not report it
+func (r *HealthResponse) ClusterManagerDiscovered() bool {
+	if r.DiscoveredClusterManager != nil {
+		return *r.DiscoveredClusterManager
+	}
+
+	return r.DiscoveredMaster == nil || *r.DiscoveredMaster
+}
+
+// DistributionOpenSearch is the distribution reported by OpenSearch in the root endpoint
+const DistributionOpenSearch = "opensearch"
+
+// InfoResponse represents the answer of the root endpoint
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/rest-api-root.html
+type InfoResponse struct {
+	Name        string `json:"name"`
+	ClusterName string `json:"cluster_name"`
+	ClusterUUID string `json:"cluster_uuid"`
+	Version     struct {
+		Number      string `json:"number"`
+		BuildFlavor string `json:"build_flavor"`
+		// Only reported by OpenSearch
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+	Tagline string `json:"tagline"`
+}
+
+// IsOpenSearch returns true when the cluster runs OpenSearch
+func (r *InfoResponse) IsOpenSearch() bool {
+	return r.Version.Distribution == DistributionOpenSearch
 }
 
 // SearchResponse represents the answer to an elastic search query
 // https://www.elastic.co/guide/en/elasticsearch/reference/current/search-search.html#search-api-response-body
 type SearchResponse struct {
-	Hits  SearchHits `json:"hits"`
+	Hits SearchHits `json:"hits"`
+	ErrorResponse
+}
+
+// ErrorResponse is the body Elasticsearch returns for failed requests
+type ErrorResponse struct {
 	Error struct {
 		RootCause []ErrorRootCause `json:"root_cause,omitempty"`
-	}
+	} `json:"error"`
 }
 
 type ErrorRootCause struct {
@@ -38,7 +80,7 @@ type ErrorRootCause struct {
 }
 
 // GetErrors returns the error reasons when they are present in the response
-func (r *SearchResponse) GetErrors() string {
+func (r *ErrorResponse) GetErrors() string {
 	if len(r.Error.RootCause) == 0 {
 		return ""
 	}
@@ -92,8 +134,14 @@ type QueryString struct {
 }
 
 type NodeInfo struct {
+	Name   string     `json:"name"`
 	IP     string     `json:"ip"`
 	Ingest IngestInfo `json:"ingest"`
+	JVM    struct {
+		Mem struct {
+			HeapMaxInBytes int64 `json:"heap_max_in_bytes"`
+		} `json:"mem"`
+	} `json:"jvm"`
 }
 
 type IngestInfo struct {
@@ -113,6 +161,7 @@ type PipelineInfo struct {
 	Failed  float64 `json:"failed"`
 }
 
+// ClusterStats represents the answer of the nodes stats API, OpenSearch uses the same fields
 type ClusterStats struct {
 	Nodes       map[string]NodeInfo `json:"nodes"`
 	ClusterName string              `json:"cluster_name"`
@@ -136,8 +185,212 @@ type Snapshot struct {
 	} `json:"shards"`
 }
 
+// SnapshotResponse represents the answer of the get snapshot API, OpenSearch
+// uses the same fields but reports neither total nor remaining
 type SnapshotResponse struct {
 	Snapshots []Snapshot `json:"snapshots"`
 	Total     int        `json:"total"`
 	Remaining int        `json:"remaining"`
 }
+
+// HealthReportResponse represents the answer of the health report API (8.7+)
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/health-api.html
+type HealthReportResponse struct {
+	ClusterName string                     `json:"cluster_name"`
+	Status      string                     `json:"status"`
+	Indicators  map[string]HealthIndicator `json:"indicators"`
+}
+
+type HealthIndicator struct {
+	Status  string         `json:"status"`
+	Symptom string         `json:"symptom"`
+	Impacts []HealthImpact `json:"impacts"`
+}
+
+type HealthImpact struct {
+	ID          string   `json:"id"`
+	Severity    int      `json:"severity"`
+	Description string   `json:"description"`
+	ImpactAreas []string `json:"impact_areas"`
+}
+
+// NodesInfoResponse represents the answer of the nodes info API
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cluster-nodes-info.html
+type NodesInfoResponse struct {
+	ClusterName string               `json:"cluster_name"`
+	Nodes       map[string]NodesInfo `json:"nodes"`
+}
+
+type NodesInfo struct {
+	Name    string   `json:"name"`
+	Host    string   `json:"host"`
+	IP      string   `json:"ip"`
+	Version string   `json:"version"`
+	Roles   []string `json:"roles"`
+	JVM     struct {
+		StartTimeInMillis int64 `json:"start_time_in_millis"`
+	} `json:"jvm"`
+	HTTP struct {
+		PublishAddress string `json:"publish_address"`
+	} `json:"http"`
+}
+
+// LicenseResponse represents the answer of the license API
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/get-license.html
+type LicenseResponse struct {
+	License License `json:"license"`
+}
+
+type License struct {
+	Status             string `json:"status"`
+	UID                string `json:"uid"`
+	Type               string `json:"type"`
+	IssuedTo           string `json:"issued_to"`
+	ExpiryDateInMillis int64  `json:"expiry_date_in_millis"`
+}
+
+// Certificate represents an entry of the SSL certificates API
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/security-api-ssl.html
+type Certificate struct {
+	Path          string    `json:"path"`
+	Format        string    `json:"format"`
+	Alias         string    `json:"alias"`
+	SubjectDN     string    `json:"subject_dn"`
+	SerialNumber  string    `json:"serial_number"`
+	HasPrivateKey bool      `json:"has_private_key"`
+	Expiry        time.Time `json:"expiry"`
+}
+
+// APIKeysResponse represents the answer of the get API key information API
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/security-api-get-api-key.html
+type APIKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+type APIKey struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Creation    int64  `json:"creation"`
+	Expiration  int64  `json:"expiration"`
+	Invalidated bool   `json:"invalidated"`
+	Username    string `json:"username"`
+	Realm       string `json:"realm"`
+}
+
+// CatIndex represents an entry of the cat indices API, all values are strings
+// and the values of closed indices are empty
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cat-indices.html
+type CatIndex struct {
+	Index        string `json:"index"`
+	Health       string `json:"health"`
+	Status       string `json:"status"`
+	DocsCount    string `json:"docs.count"`
+	StoreSize    string `json:"store.size"`
+	PriStoreSize string `json:"pri.store.size"`
+}
+
+// CatShard represents an entry of the cat shards API, all values are strings
+// and the store and node of unassigned shards are empty
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cat-shards.html
+type CatShard struct {
+	Index  string `json:"index"`
+	Shard  string `json:"shard"`
+	PriRep string `json:"prirep"`
+	State  string `json:"state"`
+	Store  string `json:"store"`
+	Node   string `json:"node"`
+}
+
+// ClusterSettingsResponse represents the answer of the cluster get settings API
+// with flat settings
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cluster-get-settings.html
+type ClusterSettingsResponse struct {
+	Persistent map[string]any `json:"persistent"`
+	Transient  map[string]any `json:"transient"`
+	Defaults   map[string]any `json:"defaults"`
+}
+
+// Setting returns the effective value of a setting, transient settings take
+// precedence over persistent settings, which take precedence over the defaults
+func (r *ClusterSettingsResponse) Setting(name string) (string, bool) {
+	for _, settings := range []map[string]any{r.Transient, r.Persistent, r.Defaults} {
+		if value, ok := settings[name].(string); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// DataStreamsResponse represents the answer of the get data stream API
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/indices-get-data-stream.html
+type DataStreamsResponse struct {
+	DataStreams []DataStream `json:"data_streams"`
+}
+
+type DataStream struct {
+	Name       string `json:"name"`
+	Generation int    `json:"generation"`
+	Status     string `json:"status"`
+	Template   string `json:"template"`
+	ILMPolicy  string `json:"ilm_policy"`
+	// Lifecycle is the data stream lifecycle (8.11+), nil when not configured
+	Lifecycle *struct {
+		Enabled       *bool  `json:"enabled"`
+		DataRetention string `json:"data_retention"`
+	} `json:"lifecycle"`
+	Indices []struct {
+		IndexName string `json:"index_name"`
+		IndexUUID string `json:"index_uuid"`
+	} `json:"indices"`
+}
+
+// ISMExplain is the Index State Management state of an OpenSearch index,
+// the policy is empty when the index is not managed by ISM
+// https://opensearch.org/docs/latest/im-plugin/ism/api/#explain-index
+type ISMExplain struct {
+	PolicyID string `json:"policy_id"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+// IndexSettingsResponse represents the answer of the get index settings API
+// with flat settings and defaults
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/indices-get-settings.html
+type IndexSettingsResponse map[string]IndexSettings
+
+type IndexSettings struct {
+	Settings map[string]any `json:"settings"`
+	Defaults map[string]any `json:"defaults"`
+}
+
+// Setting returns the effective value of a setting, the settings
+// of the index take precedence over the defaults
+func (s IndexSettings) Setting(name string) (string, bool) {
+	for _, settings := range []map[string]any{s.Settings, s.Defaults} {
+		if value, ok := settings[name].(string); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// MappingsResponse represents the answer of the get mapping API
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/indices-get-mapping.html
+type MappingsResponse map[string]struct {
+	Mappings map[string]any `json:"mappings"`
+}
+
+// FieldCapsResponse represents the answer of the field capabilities API,
+// a field has multiple types when its type differs between the indices
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/search-field-caps.html
+type FieldCapsResponse struct {
+	Indices []string                        `json:"indices"`
+	Fields  map[string]map[string]FieldCaps `json:"fields"`
+}
+
+type FieldCaps struct {
+	Type string `json:"type"`
+	// Indices is only set when the field has multiple types
+	Indices []string `json:"indices"`
+}
@@ -0,0 +1,58 @@
+package elasticsearch
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version represents a major.minor.patch version number of a node
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses version numbers like 8.11.1 or 8.12.0-SNAPSHOT.
+// Missing minor and patch numbers default to zero.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+
+	// Strip qualifiers like -SNAPSHOT or -rc1
+	number, _, _ := strings.Cut(strings.TrimSpace(s), "-")
+
+	parts := strings.Split(number, ".")
+	if number == "" || len(parts) > 3 {
+		return v, fmt.Errorf("invalid version number: '%s'", s)
+	}
+
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version number: '%s'", s)
+		}
+
+		*fields[i] = n
+	}
+
+	return v, nil
+}
+
+// Compare returns -1 if v is lower than o, 0 if they are equal and 1 if v is greater than o
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major != o.Major:
+		return cmp.Compare(v.Major, o.Major)
+	case v.Minor != o.Minor:
+		return cmp.Compare(v.Minor, o.Minor)
+	default:
+		return cmp.Compare(v.Patch, o.Patch)
+	}
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,107 @@
+package icinga
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CheckResult is the request of the process-check-result action
+// https://icinga.com/docs/icinga-2/latest/doc/12-icinga2-api/#process-check-result
+type CheckResult struct {
+	Type string `json:"type"`
+	// Host is the name of the host, for the check result of a host
+	Host string `json:"host,omitempty"`
+	// Service is the full name of the service (host!service), for the check result of a service
+	Service         string   `json:"service,omitempty"`
+	ExitStatus      int      `json:"exit_status"`
+	PluginOutput    string   `json:"plugin_output"`
+	PerformanceData []string `json:"performance_data,omitempty"`
+	CheckSource     string   `json:"check_source,omitempty"`
+}
+
+// ActionResponse is the answer of an action of the Icinga 2 API
+type ActionResponse struct {
+	Results []struct {
+		Code   float64 `json:"code"`
+		Status string  `json:"status"`
+	} `json:"results"`
+	// Error and Status are set when the request failed as a whole
+	Error  float64 `json:"error"`
+	Status string  `json:"status"`
+}
+
+type Client struct {
+	URL    *url.URL
+	Client http.Client
+}
+
+func NewClient(u *url.URL, rt http.RoundTripper) *Client {
+	return &Client{
+		URL:    u,
+		Client: http.Client{Transport: rt},
+	}
+}
+
+// NewCheckResult returns the check result for a service, or
+// for the host if no service is given
+func NewCheckResult(host, service string) CheckResult {
+	if service == "" {
+		return CheckResult{Type: "Host", Host: host}
+	}
+
+	return CheckResult{Type: "Service", Service: host + "!" + service}
+}
+
+// ProcessCheckResult submits a passive check result
+func (c *Client) ProcessCheckResult(ctx context.Context, result CheckResult) error {
+	u := c.URL.JoinPath("/v1/actions/process-check-result")
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error encoding the check result: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not submit check result: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	r := &ActionResponse{}
+
+	// The body is decoded for the error messages as well
+	errDecode := json.NewDecoder(resp.Body).Decode(r)
+
+	if resp.StatusCode != http.StatusOK {
+		if r.Status != "" {
+			return fmt.Errorf("request failed for check result: %s: %s", resp.Status, r.Status)
+		}
+
+		return fmt.Errorf("request failed for check result: %s", resp.Status)
+	}
+
+	if errDecode != nil {
+		return fmt.Errorf("error parsing the response body: %w", errDecode)
+	}
+
+	for _, res := range r.Results {
+		if res.Code != http.StatusOK {
+			return fmt.Errorf("check result not processed: %s", res.Status)
+		}
+	}
+
+	return nil
+}
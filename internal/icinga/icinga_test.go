@@ -0,0 +1,66 @@
+package icinga
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProcessCheckResult(t *testing.T) {
+	var received CheckResult
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/actions/process-check-result" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&received)
+
+		if received.Service == "unknown!health" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":404,"status":"No objects found."}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results":[{"code":200,"status":"Successfully processed check result for object 'es!health'."}]}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	c := NewClient(u, http.DefaultTransport)
+
+	result := NewCheckResult("es", "health")
+	result.ExitStatus = 1
+	result.PluginOutput = "[WARNING] - Cluster test is yellow"
+	result.PerformanceData = []string{"nodes=1"}
+
+	err := c.ProcessCheckResult(context.Background(), result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Type != "Service" || received.Service != "es!health" || received.ExitStatus != 1 ||
+		received.PluginOutput != result.PluginOutput || len(received.PerformanceData) != 1 {
+		t.Errorf("unexpected check result: %+v", received)
+	}
+
+	err = c.ProcessCheckResult(context.Background(), NewCheckResult("unknown", "health"))
+	expected := "request failed for check result: 404 Not Found: No objects found."
+
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestNewCheckResult(t *testing.T) {
+	host := NewCheckResult("es", "")
+
+	if host.Type != "Host" || host.Host != "es" || host.Service != "" {
+		t.Errorf("unexpected host check result: %+v", host)
+	}
+}